@@ -385,7 +385,7 @@ func testAcceptance(t *testing.T, when spec.G, it spec.S) {
 						h.DockerRmi(dockerCli, runImageName)
 					})
 
-					it.Pend("fails with a message", func() {
+					it("fails with a message", func() {
 						cmd := packCmd(
 							"build", repoName,
 							"-p", "testdata/node_app/.",
@@ -824,6 +824,21 @@ func testAcceptance(t *testing.T, when spec.G, it spec.S) {
 				t.Fatalf(`Expected output to contain "Third Dep Contents", got "%s"`, runOutput)
 			}
 		})
+
+		it("creates the same builder when run twice with the same inputs", func() {
+			secondBuilderRepoName := "some-org/" + h.RandString(10)
+			defer dockerCli.ImageRemove(context.TODO(), secondBuilderRepoName, dockertypes.ImageRemoveOptions{Force: true, PruneChildren: true})
+
+			h.Run(t, packCmd("create-builder", builderRepoName, "-b", builderTOML))
+			h.Run(t, packCmd("create-builder", secondBuilderRepoName, "-b", builderTOML, "--no-pull"))
+
+			first, _, err := dockerCli.ImageInspectWithRaw(context.TODO(), builderRepoName)
+			h.AssertNil(t, err)
+			second, _, err := dockerCli.ImageInspectWithRaw(context.TODO(), secondBuilderRepoName)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, second.ID, first.ID)
+		})
 	})
 
 	when("pack set-default-builder", func() {
@@ -0,0 +1,203 @@
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	FormatTar     Format = "tar"
+	FormatTarGZ   Format = "tar.gz"
+	FormatTarXZ   Format = "tar.xz"
+	FormatTarZstd Format = "tar.zst"
+	FormatZip     Format = "zip"
+)
+
+var magicNumbers = []struct {
+	format Format
+	offset int
+	magic  []byte
+}{
+	{FormatTarGZ, 0, []byte{0x1f, 0x8b}},
+	{FormatTarXZ, 0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{FormatTarZstd, 0, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{FormatZip, 0, []byte{'P', 'K', 0x03, 0x04}},
+	{FormatZip, 0, []byte{'P', 'K', 0x05, 0x06}},
+	{FormatTar, 257, []byte("ustar")},
+}
+
+// tarHeaderSize is the number of bytes needed to detect any format.
+const tarHeaderSize = 512
+
+// DetectFormat detects the format of an archive from its first bytes.
+func DetectFormat(header []byte) (Format, error) {
+	for _, m := range magicNumbers {
+		if len(header) >= m.offset+len(m.magic) && bytes.Equal(header[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.format, nil
+		}
+	}
+	return "", errors.New("unrecognized archive format, expected tar, tar.gz, tar.xz, tar.zst or zip")
+}
+
+// ExtractFile extracts the archive at path into dest. The format of the archive is detected from its content, so
+// the file name does not matter. Archives compressed with xz or zstd are decompressed by the xz and zstd commands.
+func ExtractFile(path, dest string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "could not open archive %q", path)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, tarHeaderSize)
+	header, err := reader.Peek(tarHeaderSize)
+	if err != nil && err != io.EOF {
+		return errors.Wrapf(err, "reading archive %q", path)
+	}
+	format, err := DetectFormat(header)
+	if err != nil {
+		return errors.Wrapf(err, "reading archive %q", path)
+	}
+
+	switch format {
+	case FormatTar:
+		return ExtractTar(reader, dest)
+	case FormatTarGZ:
+		return ExtractTarGZ(reader, dest)
+	case FormatTarXZ:
+		return extractCompressedTar(reader, dest, format, "xz")
+	case FormatTarZstd:
+		return extractCompressedTar(reader, dest, format, "zstd")
+	case FormatZip:
+		fi, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		return ExtractZip(file, fi.Size(), dest)
+	default:
+		return fmt.Errorf("unsupported archive format %s", format)
+	}
+}
+
+// extractCompressedTar extracts a tar compressed with a format the standard library cannot decompress, using the
+// given command, which must be installed at runtime.
+func extractCompressedTar(r io.Reader, dest string, format Format, command string) error {
+	if _, err := exec.LookPath(command); err != nil {
+		return fmt.Errorf("extracting %s archives requires the %s command to be installed", format, command)
+	}
+
+	cmd := exec.Command(command, "--decompress", "--stdout")
+	cmd.Stdin = r
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	extractErr := ExtractTar(out, dest)
+	if extractErr != nil {
+		io.Copy(ioutil.Discard, out)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s: %s", command, strings.TrimSpace(stderr.String()))
+	}
+	return extractErr
+}
+
+// ExtractZip extracts a zip archive of the given size into dest.
+func ExtractZip(r io.ReaderAt, size int64, dest string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return errors.Wrap(err, "failed to create zip reader")
+	}
+
+	for _, f := range zr.File {
+		path := filepath.Join(dest, filepath.FromSlash(f.Name))
+		if !withinDir(dest, path) {
+			return fmt.Errorf("zip entry %q is outside of the extraction directory", f.Name)
+		}
+		mode := f.Mode()
+
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(path, mode.Perm()|0700); err != nil {
+				return err
+			}
+		case mode&os.ModeSymlink != 0:
+			target, err := readZipFile(f)
+			if err != nil {
+				return err
+			}
+			// symlinks could otherwise be followed by later entries to write outside of dest
+			if filepath.IsAbs(string(target)) || !withinDir(dest, filepath.Join(filepath.Dir(path), string(target))) {
+				return fmt.Errorf("zip entry %q links to %q, which is outside of the extraction directory", f.Name, target)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(string(target), path); err != nil {
+				return err
+			}
+		default:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := writeZipFile(f, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// withinDir reports whether the cleaned path is dir or inside it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func writeZipFile(f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		// zip archives created without unix permissions
+		mode = 0644
+	}
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	_, err = io.Copy(fh, rc)
+	return err
+}
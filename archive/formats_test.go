@@ -0,0 +1,191 @@
+package archive_test
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/archive"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestFormats(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Formats", testFormats, spec.Report(report.Terminal{}))
+}
+
+func testFormats(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir, src, dest string
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "formats-test")
+		h.AssertNil(t, err)
+		src = filepath.Join("testdata", "dir-to-tar")
+		dest = filepath.Join(tmpDir, "dest")
+		h.AssertNil(t, os.MkdirAll(dest, 0755))
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	// createTar writes the test directory to a tar, ignoring the name to make sure the format is detected from content
+	createTar := func() string {
+		tarFile := filepath.Join(tmpDir, "archive.unknown")
+		h.AssertNil(t, archive.CreateTar(tarFile, src, "/some-dir", 0, 0))
+		return tarFile
+	}
+
+	compress := func(tarFile, command string) string {
+		if _, err := exec.LookPath(command); err != nil {
+			t.Skipf("%s is not installed", command)
+		}
+		out, err := exec.Command(command, "--stdout", tarFile).Output()
+		h.AssertNil(t, err)
+		compressed := filepath.Join(tmpDir, "compressed.archive")
+		h.AssertNil(t, ioutil.WriteFile(compressed, out, 0644))
+		return compressed
+	}
+
+	assertExtracted := func() {
+		t.Helper()
+		h.AssertDirContainsFileWithContents(t, filepath.Join(dest, "some-dir"), "some-file.txt", "some-content")
+		if runtime.GOOS != "windows" {
+			target, err := os.Readlink(filepath.Join(dest, "some-dir", "sub-dir", "link-file"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, target, "../some-file.txt")
+		}
+	}
+
+	when("#ExtractFile", func() {
+		it("extracts a tar", func() {
+			h.AssertNil(t, archive.ExtractFile(createTar(), dest))
+			assertExtracted()
+		})
+
+		it("extracts a gzipped tar", func() {
+			tarFile := createTar()
+			contents, err := ioutil.ReadFile(tarFile)
+			h.AssertNil(t, err)
+
+			gzFile := filepath.Join(tmpDir, "archive.zip")
+			fh, err := os.Create(gzFile)
+			h.AssertNil(t, err)
+			gzw := gzip.NewWriter(fh)
+			_, err = gzw.Write(contents)
+			h.AssertNil(t, err)
+			h.AssertNil(t, gzw.Close())
+			h.AssertNil(t, fh.Close())
+
+			h.AssertNil(t, archive.ExtractFile(gzFile, dest))
+			assertExtracted()
+		})
+
+		it("extracts an xz compressed tar", func() {
+			h.AssertNil(t, archive.ExtractFile(compress(createTar(), "xz"), dest))
+			assertExtracted()
+		})
+
+		it("extracts a zstd compressed tar", func() {
+			h.AssertNil(t, archive.ExtractFile(compress(createTar(), "zstd"), dest))
+			assertExtracted()
+		})
+
+		it("extracts a zip", func() {
+			zipFile := filepath.Join(tmpDir, "archive.tgz")
+			fh, err := os.Create(zipFile)
+			h.AssertNil(t, err)
+			zw := zip.NewWriter(fh)
+
+			dirHeader := &zip.FileHeader{Name: "some-dir/sub-dir/"}
+			dirHeader.SetMode(os.ModeDir | 0755)
+			_, err = zw.CreateHeader(dirHeader)
+			h.AssertNil(t, err)
+
+			fileHeader := &zip.FileHeader{Name: "some-dir/some-file.txt", Method: zip.Deflate}
+			fileHeader.SetMode(0755)
+			w, err := zw.CreateHeader(fileHeader)
+			h.AssertNil(t, err)
+			_, err = io.WriteString(w, "some-content")
+			h.AssertNil(t, err)
+
+			linkHeader := &zip.FileHeader{Name: "some-dir/sub-dir/link-file"}
+			linkHeader.SetMode(os.ModeSymlink | 0777)
+			w, err = zw.CreateHeader(linkHeader)
+			h.AssertNil(t, err)
+			_, err = io.WriteString(w, "../some-file.txt")
+			h.AssertNil(t, err)
+
+			h.AssertNil(t, zw.Close())
+			h.AssertNil(t, fh.Close())
+
+			h.AssertNil(t, archive.ExtractFile(zipFile, dest))
+			assertExtracted()
+			if runtime.GOOS != "windows" {
+				fi, err := os.Stat(filepath.Join(dest, "some-dir", "some-file.txt"))
+				h.AssertNil(t, err)
+				h.AssertEq(t, fi.Mode().Perm(), os.FileMode(0755))
+			}
+		})
+
+		when("a zip entry is outside of the destination", func() {
+			createZip := func(name string, mode os.FileMode, contents string) string {
+				zipFile := filepath.Join(tmpDir, "malicious.zip")
+				fh, err := os.Create(zipFile)
+				h.AssertNil(t, err)
+				zw := zip.NewWriter(fh)
+				header := &zip.FileHeader{Name: name}
+				header.SetMode(mode)
+				w, err := zw.CreateHeader(header)
+				h.AssertNil(t, err)
+				_, err = io.WriteString(w, contents)
+				h.AssertNil(t, err)
+				h.AssertNil(t, zw.Close())
+				h.AssertNil(t, fh.Close())
+				return zipFile
+			}
+
+			it("fails for a file whose path leaves the destination", func() {
+				err := archive.ExtractFile(createZip("../../escaped.txt", 0644, "some-content"), dest)
+				h.AssertError(t, err, `zip entry "../../escaped.txt" is outside of the extraction directory`)
+
+				_, err = os.Stat(filepath.Join(tmpDir, "escaped.txt"))
+				h.AssertEq(t, os.IsNotExist(err), true)
+			})
+
+			it("fails for a symlink to a path outside of the destination", func() {
+				err := archive.ExtractFile(createZip("some-link", os.ModeSymlink|0777, "../../etc"), dest)
+				h.AssertError(t, err, `zip entry "some-link" links to "../../etc", which is outside of the extraction directory`)
+
+				_, err = os.Lstat(filepath.Join(dest, "some-link"))
+				h.AssertEq(t, os.IsNotExist(err), true)
+			})
+
+			it("fails for a symlink to an absolute path", func() {
+				err := archive.ExtractFile(createZip("some-link", os.ModeSymlink|0777, "/etc"), dest)
+				h.AssertError(t, err, `zip entry "some-link" links to "/etc", which is outside of the extraction directory`)
+			})
+		})
+
+		it("fails for an unknown format", func() {
+			file := filepath.Join(tmpDir, "archive.tgz")
+			h.AssertNil(t, ioutil.WriteFile(file, []byte("not an archive"), 0644))
+
+			err := archive.ExtractFile(file, dest)
+			h.AssertError(t, err, "unrecognized archive format, expected tar, tar.gz, tar.xz, tar.zst or zip")
+		})
+	})
+}
@@ -26,7 +26,18 @@ func CreateTar(tarFile, srcDir, tarDir string, uid, gid int) error {
 		return fmt.Errorf("create file for tar: %s", err)
 	}
 	defer fh.Close()
-	return writeTarArchive(fh, srcDir, tarDir, uid, gid)
+	return writeTarArchive(fh, srcDir, tarDir, uid, gid, false)
+}
+
+// CreateNormalizedTar is like CreateTar, but also normalizes file modes, so that the same source files always
+// produce the same tar regardless of the umask they were created with.
+func CreateNormalizedTar(tarFile, srcDir, tarDir string, uid, gid int) error {
+	fh, err := os.Create(tarFile)
+	if err != nil {
+		return fmt.Errorf("create file for tar: %s", err)
+	}
+	defer fh.Close()
+	return writeTarArchive(fh, srcDir, tarDir, uid, gid, true)
 }
 
 func CreateTarReader(srcDir, tarDir string, uid, gid int) (io.Reader, chan error) {
@@ -34,7 +45,7 @@ func CreateTarReader(srcDir, tarDir string, uid, gid int) (io.Reader, chan error
 	errChan := make(chan error, 1)
 	go func() {
 		defer w.Close()
-		err := writeTarArchive(w, srcDir, tarDir, uid, gid)
+		err := writeTarArchive(w, srcDir, tarDir, uid, gid, false)
 		w.Close()
 		errChan <- err
 	}()
@@ -88,6 +99,9 @@ func ExtractTar(r io.Reader, dest string) error {
 		}
 
 		path := filepath.Join(dest, hdr.Name)
+		if !withinDir(dest, path) {
+			return fmt.Errorf("tar entry %q is outside of the extraction directory", hdr.Name)
+		}
 
 		switch hdr.Typeflag {
 		case tar.TypeDir:
@@ -114,9 +128,15 @@ func ExtractTar(r io.Reader, dest string) error {
 
 			fh.Close()
 		case tar.TypeSymlink:
+			// symlinks could otherwise be followed by later entries to write outside of dest
+			if filepath.IsAbs(hdr.Linkname) || !withinDir(dest, filepath.Join(filepath.Dir(path), hdr.Linkname)) {
+				return fmt.Errorf("tar entry %q links to %q, which is outside of the extraction directory", hdr.Name, hdr.Linkname)
+			}
 			if err := os.Symlink(hdr.Linkname, path); err != nil {
 				return err
 			}
+		case tar.TypeXGlobalHeader:
+			// written by e.g. git archive, applies to no file
 		default:
 			return fmt.Errorf("unknown file type in tar %d", hdr.Typeflag)
 		}
@@ -157,19 +177,33 @@ func writeParentDirectoryHeaders(tarDir string, tw *tar.Writer, uid int, gid int
 }
 
 func isNotRootDir(parent string) bool {
+	if parent == "." {
+		return false
+	}
 	if runtime.GOOS == "windows" {
 		return parent != "\\"
 	}
 	return parent != "/"
 }
 
-func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int) error {
+func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int, normalizeModes bool) error {
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
-	err := writeParentDirectoryHeaders(tarDir, tw, uid, gid)
-	if err != nil {
-		return err
+	return writeDir(tw, srcDir, tarDir, uid, gid, normalizeModes)
+}
+
+// WriteDirToTar writes the contents of srcDir to tw below tarDir, with normalized file modes. An empty tarDir writes
+// the contents at the root of the archive.
+func WriteDirToTar(tw *tar.Writer, srcDir, tarDir string, uid, gid int) error {
+	return writeDir(tw, srcDir, tarDir, uid, gid, true)
+}
+
+func writeDir(tw *tar.Writer, srcDir, tarDir string, uid, gid int, normalizeModes bool) error {
+	if tarDir != "" {
+		if err := writeParentDirectoryHeaders(tarDir, tw, uid, gid); err != nil {
+			return err
+		}
 	}
 
 	return filepath.Walk(srcDir, func(file string, fi os.FileInfo, err error) error {
@@ -211,6 +245,9 @@ func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int) error {
 		header.Gid = gid
 		header.Uname = ""
 		header.Gname = ""
+		if normalizeModes {
+			header.Mode = normalizedMode(fi)
+		}
 
 		if err := tw.WriteHeader(header); err != nil {
 			return err
@@ -231,3 +268,16 @@ func writeTarArchive(w io.Writer, srcDir, tarDir string, uid, gid int) error {
 		return nil
 	})
 }
+
+func normalizedMode(fi os.FileInfo) int64 {
+	switch {
+	case fi.IsDir():
+		return 0755
+	case fi.Mode()&os.ModeSymlink != 0:
+		return 0777
+	case fi.Mode()&0111 != 0:
+		return 0755
+	default:
+		return 0644
+	}
+}
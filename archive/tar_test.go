@@ -2,6 +2,8 @@ package archive_test
 
 import (
 	"archive/tar"
+	"bytes"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -16,6 +18,7 @@ import (
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpack/pack/archive"
+	h "github.com/buildpack/pack/testhelpers"
 )
 
 func TestArchive(t *testing.T) {
@@ -67,6 +70,115 @@ func testArchive(t *testing.T, when spec.G, it spec.S) {
 			verify.nextSymLink("/nested/dir/dir-in-archive/sub-dir/link-file", "../some-file.txt")
 		}
 	})
+
+	it("normalizes file modes when creating a normalized tar", func() {
+		if runtime.GOOS == "windows" {
+			t.Skip("file modes are not supported on windows")
+		}
+		src := filepath.Join(tmpDir, "src")
+		h.AssertNil(t, os.MkdirAll(filepath.Join(src, "private-dir"), 0700))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(src, "private-dir", "exec-file"), []byte("exec"), 0700))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(src, "private-dir", "some-file"), []byte("some"), 0600))
+
+		tarFile := filepath.Join(tmpDir, "some.tar")
+		h.AssertNil(t, archive.CreateNormalizedTar(tarFile, src, "/dir-in-archive", 0, 0))
+
+		file, err := os.Open(tarFile)
+		h.AssertNil(t, err)
+		defer file.Close()
+
+		modes := map[string]int64{}
+		tr := tar.NewReader(file)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			h.AssertNil(t, err)
+			modes[header.Name] = header.Mode
+		}
+		h.AssertEq(t, modes, map[string]int64{
+			"/dir-in-archive":                       0755,
+			"/dir-in-archive/private-dir":           0755,
+			"/dir-in-archive/private-dir/exec-file": 0755,
+			"/dir-in-archive/private-dir/some-file": 0644,
+		})
+	})
+
+	it("writes a directory to an existing tar with relative paths", func() {
+		tarFile := filepath.Join(tmpDir, "some.tar")
+		file, err := os.Create(tarFile)
+		h.AssertNil(t, err)
+		defer file.Close()
+
+		tw := tar.NewWriter(file)
+		h.AssertNil(t, archive.WriteDirToTar(tw, src, "", 1234, 2345))
+		h.AssertNil(t, archive.WriteDirToTar(tw, src, "nested/dir", 1234, 2345))
+		h.AssertNil(t, tw.Close())
+
+		_, err = file.Seek(0, io.SeekStart)
+		h.AssertNil(t, err)
+		verify := tarVerifier{t, tar.NewReader(file), 1234, 2345}
+		verify.nextFile("some-file.txt", "some-content")
+		verify.nextDirectory("sub-dir", 0755)
+		if runtime.GOOS != "windows" {
+			verify.nextSymLink("sub-dir/link-file", "../some-file.txt")
+		}
+		verify.nextDirectory("nested", 0755)
+		verify.nextDirectory("nested/dir", 0755)
+		verify.nextFile("nested/dir/some-file.txt", "some-content")
+	})
+
+	when("#ExtractTar", func() {
+		var dest string
+
+		it.Before(func() {
+			dest = filepath.Join(tmpDir, "dest")
+			h.AssertNil(t, os.MkdirAll(dest, 0755))
+		})
+
+		createTar := func(hdr *tar.Header, contents string) io.Reader {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			hdr.Size = int64(len(contents))
+			h.AssertNil(t, tw.WriteHeader(hdr))
+			_, err := tw.Write([]byte(contents))
+			h.AssertNil(t, err)
+			h.AssertNil(t, tw.Close())
+			return &buf
+		}
+
+		it("extracts files and symlinks inside the destination", func() {
+			h.AssertNil(t, archive.ExtractTar(createTar(&tar.Header{Name: "some-dir/some-file.txt", Typeflag: tar.TypeReg, Mode: 0644}, "some-content"), dest))
+			h.AssertDirContainsFileWithContents(t, filepath.Join(dest, "some-dir"), "some-file.txt", "some-content")
+
+			h.AssertNil(t, archive.ExtractTar(createTar(&tar.Header{Name: "some-dir/link-file", Typeflag: tar.TypeSymlink, Linkname: "some-file.txt"}, ""), dest))
+			target, err := os.Readlink(filepath.Join(dest, "some-dir", "link-file"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, target, "some-file.txt")
+		})
+
+		it("fails for a file whose path leaves the destination", func() {
+			err := archive.ExtractTar(createTar(&tar.Header{Name: "../../escaped.txt", Typeflag: tar.TypeReg, Mode: 0644}, "some-content"), dest)
+			h.AssertError(t, err, `tar entry "../../escaped.txt" is outside of the extraction directory`)
+
+			_, err = os.Stat(filepath.Join(tmpDir, "escaped.txt"))
+			h.AssertEq(t, os.IsNotExist(err), true)
+		})
+
+		it("fails for a symlink to a path outside of the destination", func() {
+			err := archive.ExtractTar(createTar(&tar.Header{Name: "some-link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}, ""), dest)
+			h.AssertError(t, err, `tar entry "some-link" links to "../../etc", which is outside of the extraction directory`)
+
+			_, err = os.Lstat(filepath.Join(dest, "some-link"))
+			h.AssertEq(t, os.IsNotExist(err), true)
+		})
+
+		it("fails for a symlink to an absolute path", func() {
+			err := archive.ExtractTar(createTar(&tar.Header{Name: "some-link", Typeflag: tar.TypeSymlink, Linkname: "/etc"}, ""), dest)
+			h.AssertError(t, err, `tar entry "some-link" links to "/etc", which is outside of the extraction directory`)
+		})
+	})
 }
 
 func fileMode(t *testing.T, path string) int64 {
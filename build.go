@@ -12,10 +12,12 @@ import (
 
 	"github.com/buildpack/pack/build"
 	"github.com/buildpack/pack/builder"
+	"github.com/buildpack/pack/buildpack"
 	"github.com/buildpack/pack/cache"
 	"github.com/buildpack/pack/config"
 	"github.com/buildpack/pack/docker"
 	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/sbom"
 	"github.com/buildpack/pack/style"
 
 	lcimg "github.com/buildpack/lifecycle/image"
@@ -29,11 +31,12 @@ type Cache interface {
 }
 
 type BuildFactory struct {
-	Cli     Docker
-	Logger  *logging.Logger
-	Config  *config.Config
-	Cache   Cache
-	Fetcher Fetcher
+	Cli              Docker
+	Logger           *logging.Logger
+	Config           *config.Config
+	Cache            Cache
+	Fetcher          Fetcher
+	BuildpackFetcher BuildpackFetcher // fetches git+ buildpacks, defaults to a fetcher using the pack home
 }
 
 type BuildFlags struct {
@@ -46,6 +49,8 @@ type BuildFlags struct {
 	Publish    bool
 	NoPull     bool
 	ClearCache bool
+	Force      bool
+	SBOMDir    string
 	Buildpacks []string
 }
 
@@ -55,10 +60,12 @@ type BuildConfig struct {
 	RepoName   string
 	Publish    bool
 	ClearCache bool
+	SBOMDir    string
 	// Above are copied from BuildFlags are set by init
-	Cli    Docker
-	Logger *logging.Logger
-	Config *config.Config
+	Cli     Docker
+	Logger  *logging.Logger
+	Config  *config.Config
+	Fetcher Fetcher
 	// Above are copied from BuildFactory
 	Cache           Cache
 	LifecycleConfig build.LifecycleConfig
@@ -130,9 +137,11 @@ func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags)
 		RepoName:   f.RepoName,
 		Publish:    f.Publish,
 		ClearCache: f.ClearCache,
+		SBOMDir:    f.SBOMDir,
 		Cli:        bf.Cli,
 		Logger:     bf.Logger,
 		Config:     bf.Config,
+		Fetcher:    bf.Fetcher,
 	}
 
 	var env map[string]string
@@ -216,13 +225,26 @@ func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags)
 		}
 	}
 
+	builderStackID, err := builderImage.GetStack()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRunImageStack(bf.Logger, b.Builder, builderStackID, runImage, f.Force); err != nil {
+		return nil, err
+	}
+
 	b.Cache = bf.Cache
 	bf.Logger.Verbose(fmt.Sprintf("Using cache image %s", style.Symbol(b.Cache.Image())))
 
+	buildpacks, err := bf.resolveBuildpacks(f.Buildpacks)
+	if err != nil {
+		return nil, err
+	}
+
 	b.LifecycleConfig = build.LifecycleConfig{
 		BuilderImage: b.Builder,
 		Logger:       b.Logger,
-		Buildpacks:   f.Buildpacks,
+		Buildpacks:   buildpacks,
 		Env:          env,
 		AppDir:       appDir,
 	}
@@ -230,6 +252,30 @@ func (bf *BuildFactory) BuildConfigFromFlags(ctx context.Context, f *BuildFlags)
 	return b, nil
 }
 
+// resolveBuildpacks fetches the buildpacks given by git+ URIs, which are passed on to the lifecycle as the directories
+// they were fetched to. Other buildpacks are passed on as they are.
+func (bf *BuildFactory) resolveBuildpacks(buildpacks []string) ([]string, error) {
+	bpFetcher := bf.BuildpackFetcher
+	if bpFetcher == nil {
+		bpFetcher = buildpack.NewFetcher(bf.Logger, bf.Fetcher, bf.Config.Path())
+	}
+
+	var resolved []string
+	for _, bp := range buildpacks {
+		if !strings.HasPrefix(bp, "git+") {
+			resolved = append(resolved, bp)
+			continue
+		}
+		fetched, err := bpFetcher.FetchBuildpack("", buildpack.Buildpack{URI: bp})
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching buildpack %s", style.Symbol(bp))
+		}
+		bf.Logger.Verbose("Using buildpack %s at commit %s", style.Symbol(bp), fetched.Commit)
+		resolved = append(resolved, fetched.Dir)
+	}
+	return resolved, nil
+}
+
 func Build(ctx context.Context, outWriter, errWriter io.Writer, appDir, builderImage, runImage, repoName string, publish, clearCache bool) error {
 	// TODO: Receive Cache as an argument of this function
 	dockerClient, err := docker.New()
@@ -317,6 +363,13 @@ func (b *BuildConfig) Run(ctx context.Context) error {
 		return err
 	}
 
+	if b.SBOMDir != "" {
+		b.Logger.Verbose(style.Step("WRITING SBOM"))
+		if err := b.writeSBOM(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -374,6 +427,31 @@ func (b *BuildConfig) cache(ctx context.Context, lifecycle *build.Lifecycle) err
 	return cache.Run(ctx)
 }
 
+func (b *BuildConfig) writeSBOM() error {
+	var (
+		img lcimg.Image
+		err error
+	)
+	if b.Publish {
+		img, err = b.Fetcher.FetchRemoteImage(b.RepoName)
+	} else {
+		img, err = b.Fetcher.FetchLocalImage(b.RepoName)
+	}
+	if err != nil {
+		return err
+	}
+
+	bom, err := sbom.FromImage(img)
+	if err != nil {
+		return err
+	}
+	if err := bom.WriteDir(b.SBOMDir); err != nil {
+		return err
+	}
+	b.Logger.Verbose("SBOM written to %s", style.Symbol(b.SBOMDir))
+	return nil
+}
+
 func parseEnvFile(filename string) (map[string]string, error) {
 	out := make(map[string]string, 0)
 	f, err := ioutil.ReadFile(filename)
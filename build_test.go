@@ -67,10 +67,12 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("defaults to daemon, default-builder, pulls builder and run images, selects run-image from builder", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
 
@@ -85,10 +87,12 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("respects builder from flags", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "custom/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
 
@@ -103,10 +107,12 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("doesn't pull builder or run images when --no-pull is passed", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchLocalImage("custom/builder").Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockRunImage, nil)
 
@@ -122,11 +128,13 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("selects run images with matching registry", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").
 				Return(`{"stack":{"runImage": {"image": "some/run", "mirrors": ["registry.com/some/run"]}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "registry.com/some/run", gomock.Any()).Return(mockRunImage, nil)
 
@@ -151,11 +159,13 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 				}
 
 				mockBuilderImage := mocks.NewMockImage(mockController)
+				mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 				mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").
 					Return(`{"stack":{"runImage": {"image": "default/run", "mirrors": ["registry.com/default/run"]}}}`, nil).AnyTimes()
 				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 				mockRunImage = mocks.NewMockImage(mockController)
+				mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 				mockRunImage.EXPECT().Found().Return(true, nil)
 			})
 
@@ -186,10 +196,12 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("uses a remote run image when --publish is passed", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockRunImage, nil)
 
@@ -205,9 +217,11 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("allows run-image from flags if the stacks match", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchRemoteImage("override/run").Return(mockRunImage, nil)
 
@@ -222,12 +236,51 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, config.Builder, "some/builder")
 		})
 
+		when("the run image stack does not match the builder stack", func() {
+			it.Before(func() {
+				mockBuilderImage := mocks.NewMockImage(mockController)
+				mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
+
+				mockRunImage := mocks.NewMockImage(mockController)
+				mockRunImage.EXPECT().Name().Return("override/run").AnyTimes()
+				mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("other.stack.id", nil).AnyTimes()
+				mockRunImage.EXPECT().Found().Return(true, nil)
+				mockFetcher.EXPECT().FetchRemoteImage("override/run").Return(mockRunImage, nil)
+			})
+
+			it("returns an error naming both stacks", func() {
+				_, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName: "some/app",
+					Builder:  "some/builder",
+					RunImage: "override/run",
+					Publish:  true,
+				})
+				h.AssertError(t, err, "wrong stack: run image 'override/run' has stack 'other.stack.id' but 'some/builder' has stack 'some.stack.id' -- use --force to override")
+			})
+
+			it("uses the run image anyway when forced", func() {
+				config, err := factory.BuildConfigFromFlags(context.TODO(), &pack.BuildFlags{
+					RepoName: "some/app",
+					Builder:  "some/builder",
+					RunImage: "override/run",
+					Publish:  true,
+					Force:    true,
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, config.RunImage, "override/run")
+				h.AssertContains(t, outBuf.String(), "Warning: wrong stack")
+			})
+		})
+
 		it("uses working dir if appDir is set to placeholder value", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockRunImage, nil)
 
@@ -245,6 +298,7 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("returns an error when the builder metadata label is missing", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Name().Return("some/builder")
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return("", nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
@@ -258,6 +312,7 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("returns an error when the builder metadata label is unparsable", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Name().Return("some/builder")
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return("junk", nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
@@ -271,9 +326,11 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("returns an error if remote run image doesn't exist in remote on published builds", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(false, nil)
 			mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockRunImage, nil)
 
@@ -288,9 +345,11 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("returns an error if local run image doesn't exist locally on local builds", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(false, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
 
@@ -305,10 +364,12 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("sets Env", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
 
@@ -332,10 +393,12 @@ func testBuildFactory(t *testing.T, when spec.G, it spec.S) {
 
 		it("sets EnvFile", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
 
@@ -367,10 +430,12 @@ PATH
 
 		it("sets EnvFile with Env overrides", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockBuilderImage.EXPECT().Label("io.buildpacks.builder.metadata").Return(`{"stack":{"runImage": {"image": "some/run"}}}`, nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
 
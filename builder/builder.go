@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/stack"
 	"github.com/buildpack/pack/style"
 )
 
@@ -25,16 +26,16 @@ func NewBuilder(img image.Image, cfg *config.Config) *Builder {
 }
 
 func (b *Builder) GetStack() (string, error) {
-	stack, err := b.image.Label("io.buildpacks.stack.id")
+	stackID, err := b.image.Label(stack.IDLabel)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to find stack label for builder %s", style.Symbol(b.image.Name()))
 	}
 
-	if stack == "" {
-		return "", fmt.Errorf("builder %s missing label %s -- try recreating builder", style.Symbol(b.image.Name()), style.Symbol("io.buildpacks.stack.id"))
+	if stackID == "" {
+		return "", fmt.Errorf("builder %s missing label %s -- try recreating builder", style.Symbol(b.image.Name()), style.Symbol(stack.IDLabel))
 	}
 
-	return stack, nil
+	return stackID, nil
 }
 
 func (b *Builder) GetMetadata() (*Metadata, error) {
@@ -1,6 +1,8 @@
 package builder
 
 import (
+	"time"
+
 	"github.com/buildpack/lifecycle"
 
 	"github.com/buildpack/pack/buildpack"
@@ -10,9 +12,12 @@ import (
 const MetadataLabel = "io.buildpacks.builder.metadata"
 
 type TOML struct {
-	Buildpacks []buildpack.Buildpack      `toml:"buildpacks"`
-	Groups     []lifecycle.BuildpackGroup `toml:"groups"`
-	Stack      Stack
+	Description string                     `toml:"description"`
+	Buildpacks  []buildpack.Buildpack      `toml:"buildpacks"`
+	Groups      []lifecycle.BuildpackGroup `toml:"groups"`
+	Stack       Stack
+	Lifecycle   Lifecycle              `toml:"lifecycle"`
+	Metadata    map[string]interface{} `toml:"metadata"`
 }
 
 type Stack struct {
@@ -22,16 +27,47 @@ type Stack struct {
 	RunImageMirrors []string `toml:"run-image-mirrors,omitempty"`
 }
 
+// Lifecycle selects a lifecycle to embed in the builder, either a released version or the URI of a lifecycle tarball.
+type Lifecycle struct {
+	Version string `toml:"version"`
+	URI     string `toml:"uri"`
+	SHA256  string `toml:"sha256"`
+}
+
 type Metadata struct {
-	Buildpacks []BuildpackMetadata `json:"buildpacks"`
-	Groups     []GroupMetadata     `json:"groups"`
-	Stack      stack.Metadata      `json:"stack"`
+	Description string                 `json:"description,omitempty"`
+	Buildpacks  []BuildpackMetadata    `json:"buildpacks"`
+	Groups      []GroupMetadata        `json:"groups"`
+	Stack       stack.Metadata         `json:"stack"`
+	Lifecycle   *LifecycleMetadata     `json:"lifecycle,omitempty"`
+	CreatedBy   *CreatorMetadata       `json:"createdBy,omitempty"`
+	Custom      map[string]interface{} `json:"metadata,omitempty"` // the [metadata] table of builder.toml
 }
 
-type BuildpackMetadata struct {
-	ID      string `json:"id"`
+// CreatorMetadata records how and from what a builder was created.
+type CreatorMetadata struct {
+	PackVersion      string    `json:"packVersion"`
+	Created          time.Time `json:"created"`
+	BuildImage       string    `json:"buildImage"`
+	BuildImageDigest string    `json:"buildImageDigest,omitempty"`
+}
+
+type LifecycleMetadata struct {
 	Version string `json:"version"`
-	Latest  bool   `json:"latest"`
+}
+
+type BuildpackMetadata struct {
+	ID           string   `json:"id"`
+	Version      string   `json:"version"`
+	Latest       bool     `json:"latest"`
+	Name         string   `json:"name,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Homepage     string   `json:"homepage,omitempty"`
+	Stacks       []string `json:"stacks,omitempty"`
+	Optional     bool     `json:"optional,omitempty"`
+	LayerDiffID  string   `json:"layerDiffID,omitempty"`
+	Commit       string   `json:"commit,omitempty"`
+	SourceDigest string   `json:"sourceDigest,omitempty"`
 }
 
 type GroupMetadata struct {
@@ -0,0 +1,452 @@
+package pack
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lcimg "github.com/buildpack/lifecycle/image"
+	"github.com/buildpack/lifecycle/image/auth"
+	"github.com/docker/docker/api/types/container"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/style"
+)
+
+// builderImage is the image a builder is written to. The images of the lifecycle image library record the time they
+// are saved as their creation time, so builderImage records the layers and config changes made to the builder
+// instead, and writes the image once with the creation time of the builder. Reads are answered by the base image.
+type builderImage struct {
+	lcimg.Image
+	name       string
+	baseName   string
+	created    time.Time
+	publish    bool
+	docker     Docker
+	labels     map[string]string
+	env        []string // KEY=VALUE, in the order they were set
+	entrypoint []string
+	cmd        []string
+	layers     []builderLayer
+}
+
+type builderLayer struct {
+	diffID string
+	path   string // empty for layers reused from the previous image with the same name
+}
+
+func newBuilderImage(base lcimg.Image, baseName string, created time.Time, publish bool, docker Docker) *builderImage {
+	return &builderImage{
+		Image:    base,
+		name:     baseName,
+		baseName: baseName,
+		created:  created,
+		publish:  publish,
+		docker:   docker,
+		labels:   map[string]string{},
+	}
+}
+
+func (b *builderImage) Name() string {
+	return b.name
+}
+
+func (b *builderImage) Rename(name string) {
+	b.name = name
+	b.Image.Rename(name)
+}
+
+func (b *builderImage) SetLabel(key, val string) error {
+	if err := b.Image.SetLabel(key, val); err != nil {
+		return err
+	}
+	b.labels[key] = val
+	return nil
+}
+
+func (b *builderImage) SetEnv(key, val string) error {
+	if err := b.Image.SetEnv(key, val); err != nil {
+		return err
+	}
+	b.env = append(b.env, key+"="+val)
+	return nil
+}
+
+func (b *builderImage) SetEntrypoint(ep ...string) error {
+	if err := b.Image.SetEntrypoint(ep...); err != nil {
+		return err
+	}
+	b.entrypoint = ep
+	return nil
+}
+
+func (b *builderImage) SetCmd(cmd ...string) error {
+	if err := b.Image.SetCmd(cmd...); err != nil {
+		return err
+	}
+	b.cmd = cmd
+	return nil
+}
+
+func (b *builderImage) AddLayer(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "opening layer %s", path)
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return errors.Wrapf(err, "computing diff ID of layer %s", path)
+	}
+	b.layers = append(b.layers, builderLayer{diffID: fmt.Sprintf("sha256:%x", hasher.Sum(nil)), path: path})
+	return nil
+}
+
+func (b *builderImage) ReuseLayer(diffID string) error {
+	b.layers = append(b.layers, builderLayer{diffID: diffID})
+	return nil
+}
+
+func (b *builderImage) TopLayer() (string, error) {
+	if len(b.layers) == 0 {
+		return b.Image.TopLayer()
+	}
+	return b.layers[len(b.layers)-1].diffID, nil
+}
+
+// Save writes the builder to the registry when publishing, and to the daemon otherwise. It returns the digest of the
+// published image, or the ID of the image in the daemon.
+func (b *builderImage) Save() (string, error) {
+	if b.publish {
+		return b.saveRemote()
+	}
+	return b.saveLocal()
+}
+
+func (b *builderImage) saveRemote() (string, error) {
+	// the base image is read again at the digest it had when the builder was started
+	baseRef, baseAuth, err := auth.ReferenceForRepoName(authn.DefaultKeychain, b.baseName)
+	if err != nil {
+		return "", err
+	}
+	baseDigest, err := b.Image.Digest()
+	if err != nil {
+		return "", err
+	}
+	if baseDigest != "" {
+		if baseRef, err = name.NewDigest(baseRef.Context().Name()+"@"+baseDigest, name.WeakValidation); err != nil {
+			return "", err
+		}
+	}
+	img, err := remote.Image(baseRef, remote.WithAuth(baseAuth))
+	if err != nil {
+		return "", errors.Wrapf(err, "reading base image %s", style.Symbol(b.baseName))
+	}
+
+	ref, refAuth, err := auth.ReferenceForRepoName(authn.DefaultKeychain, b.name)
+	if err != nil {
+		return "", err
+	}
+	var previous v1.Image
+	for _, layer := range b.layers {
+		var l v1.Layer
+		if layer.path != "" {
+			l, err = tarball.LayerFromFile(layer.path)
+		} else {
+			if previous == nil {
+				if previous, err = remote.Image(ref, remote.WithAuth(refAuth)); err != nil {
+					return "", errors.Wrapf(err, "reading previous image %s", style.Symbol(b.name))
+				}
+			}
+			var hash v1.Hash
+			if hash, err = v1.NewHash(layer.diffID); err == nil {
+				l, err = previous.LayerByDiffID(hash)
+			}
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "reading layer %s", layer.diffID)
+		}
+		if img, err = mutate.AppendLayers(img, l); err != nil {
+			return "", err
+		}
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return "", err
+	}
+	config := *configFile.Config.DeepCopy()
+	if config.Labels == nil {
+		config.Labels = map[string]string{}
+	}
+	for key, val := range b.labels {
+		config.Labels[key] = val
+	}
+	config.Env = setEnv(config.Env, b.env)
+	if b.entrypoint != nil {
+		config.Entrypoint = b.entrypoint
+	}
+	if b.cmd != nil {
+		config.Cmd = b.cmd
+	}
+	if img, err = mutate.Config(img, config); err != nil {
+		return "", err
+	}
+	if img, err = mutate.CreatedAt(img, v1.Time{Time: b.created}); err != nil {
+		return "", err
+	}
+
+	if err := remote.Write(ref, img, refAuth, http.DefaultTransport); err != nil {
+		return "", errors.Wrapf(err, "writing image %s", style.Symbol(b.name))
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return "", err
+	}
+	return digest.String(), nil
+}
+
+func (b *builderImage) saveLocal() (string, error) {
+	ctx := context.Background()
+	base, _, err := b.docker.ImageInspectWithRaw(ctx, b.baseName)
+	if err != nil {
+		return "", errors.Wrapf(err, "inspecting base image %s", style.Symbol(b.baseName))
+	}
+	previous, _, err := b.docker.ImageInspectWithRaw(ctx, b.name)
+	if err != nil && !dockerclient.IsErrNotFound(err) {
+		return "", errors.Wrapf(err, "inspecting previous image %s", style.Symbol(b.name))
+	}
+	tag, err := name.NewTag(b.name, name.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+
+	config := container.Config{}
+	if base.Config != nil {
+		config = *base.Config
+	}
+	config.Labels = map[string]string{}
+	if base.Config != nil {
+		for key, val := range base.Config.Labels {
+			config.Labels[key] = val
+		}
+	}
+	for key, val := range b.labels {
+		config.Labels[key] = val
+	}
+	config.Env = setEnv(config.Env, b.env)
+	if b.entrypoint != nil {
+		config.Entrypoint = b.entrypoint
+	}
+	if b.cmd != nil {
+		config.Cmd = b.cmd
+	}
+
+	diffIDs := append([]string{}, base.RootFS.Layers...)
+	for _, layer := range b.layers {
+		diffIDs = append(diffIDs, layer.diffID)
+	}
+	imgConfig, err := json.Marshal(map[string]interface{}{
+		"os":      "linux",
+		"created": b.created.UTC().Format(time.RFC3339),
+		"config":  config,
+		"rootfs": map[string]interface{}{
+			"type":     "layers",
+			"diff_ids": diffIDs,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	imageID := fmt.Sprintf("%x", sha256.Sum256(imgConfig))
+
+	// The daemon skips layers it already has, which it looks up by the layers below them as well. Layers that the base
+	// or previous image start with are therefore left out of the archive.
+	layerPaths := make([]string, len(diffIDs))
+	var prevLayers map[string]string
+	for i := len(base.RootFS.Layers); i < len(diffIDs); i++ {
+		if hasLayers(previous.RootFS.Layers, diffIDs[:i+1]) {
+			continue
+		}
+		layer := b.layers[i-len(base.RootFS.Layers)]
+		if layer.path != "" {
+			layerPaths[i] = layer.path
+			continue
+		}
+		if prevLayers == nil {
+			tmpDir, err := ioutil.TempDir("", "previous-builder")
+			if err != nil {
+				return "", err
+			}
+			defer os.RemoveAll(tmpDir)
+			if prevLayers, err = b.downloadLayers(ctx, previous.RootFS.Layers, tmpDir); err != nil {
+				return "", err
+			}
+		}
+		if layerPaths[i] = prevLayers[layer.diffID]; layerPaths[i] == "" {
+			return "", fmt.Errorf("previous image %s has no layer %s", style.Symbol(b.name), layer.diffID)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- b.writeImageArchive(pw, imageID, imgConfig, tag.String(), layerPaths)
+	}()
+	res, err := b.docker.ImageLoad(ctx, pr, true)
+	if err != nil {
+		pr.CloseWithError(err)
+		<-done
+		return "", errors.Wrapf(err, "loading image %s", style.Symbol(b.name))
+	}
+	defer res.Body.Close()
+	if _, err := io.Copy(ioutil.Discard, res.Body); err != nil {
+		return "", err
+	}
+	if err := <-done; err != nil {
+		return "", err
+	}
+	return imageID, nil
+}
+
+// writeImageArchive writes an archive of an image in the format of docker save. Layers without a path are left out.
+func (b *builderImage) writeImageArchive(w *io.PipeWriter, imageID string, imgConfig []byte, tag string, layerPaths []string) (err error) {
+	defer func() {
+		w.CloseWithError(err)
+	}()
+	tw := tar.NewWriter(w)
+
+	if err := writeTarFile(tw, imageID+".json", imgConfig); err != nil {
+		return err
+	}
+	manifestLayers := make([]string, len(layerPaths))
+	for i, path := range layerPaths {
+		if path == "" {
+			continue
+		}
+		manifestLayers[i] = fmt.Sprintf("%d.tar", i)
+		if err := addTarFile(tw, manifestLayers[i], path); err != nil {
+			return err
+		}
+	}
+	manifest, err := json.Marshal([]map[string]interface{}{{
+		"Config":   imageID + ".json",
+		"RepoTags": []string{tag},
+		"Layers":   manifestLayers,
+	}})
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, "manifest.json", manifest); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// downloadLayers saves the previous image with the name of the builder to dir, and returns the paths of its layers
+// by diff ID.
+func (b *builderImage) downloadLayers(ctx context.Context, diffIDs []string, dir string) (map[string]string, error) {
+	rc, err := b.docker.ImageSave(ctx, []string{b.name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "saving previous image %s", style.Symbol(b.name))
+	}
+	defer rc.Close()
+
+	if err := archive.ExtractTar(rc, dir); err != nil {
+		return nil, errors.Wrapf(err, "extracting previous image %s", style.Symbol(b.name))
+	}
+
+	var manifest []struct {
+		Layers []string
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err == nil {
+		err = json.Unmarshal(contents, &manifest)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading manifest of previous image %s", style.Symbol(b.name))
+	}
+	if len(manifest) != 1 || len(manifest[0].Layers) != len(diffIDs) {
+		return nil, fmt.Errorf("unexpected manifest of previous image %s", style.Symbol(b.name))
+	}
+	layers := map[string]string{}
+	for i, layer := range manifest[0].Layers {
+		layers[diffIDs[i]] = filepath.Join(dir, filepath.FromSlash(layer))
+	}
+	return layers, nil
+}
+
+// hasLayers reports whether layers starts with prefix.
+func hasLayers(layers, prefix []string) bool {
+	if len(layers) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if layers[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// setEnv sets each KEY=VALUE of vars in env, replacing earlier values of the same key.
+func setEnv(env, vars []string) []string {
+	env = append([]string{}, env...)
+	for _, v := range vars {
+		key := strings.SplitN(v, "=", 2)[0]
+		replaced := false
+		for i, e := range env {
+			if strings.SplitN(e, "=", 2)[0] == key {
+				env[i] = v
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			env = append(env, v)
+		}
+	}
+	return env
+}
+
+func writeTarFile(tw *tar.Writer, name string, contents []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}); err != nil {
+		return err
+	}
+	_, err := tw.Write(contents)
+	return err
+}
+
+func addTarFile(tw *tar.Writer, name, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: fi.Size()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
@@ -0,0 +1,204 @@
+package buildpack
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheEntry records a URI fetched into the download cache, along with the paths in the cache it was fetched to.
+type CacheEntry struct {
+	URI      string    `json:"uri"`
+	ETag     string    `json:"etag,omitempty"`
+	Digest   string    `json:"digest,omitempty"` // sha256 digest of a downloaded archive
+	Commit   string    `json:"commit,omitempty"` // commit checked out from a git repository
+	Paths    []string  `json:"paths"`            // relative to the cache directory
+	LastUsed time.Time `json:"lastUsed"`
+	Size     int64     `json:"-"`
+}
+
+const entriesDir = "entries"
+
+// CacheEntries returns the entries of the download cache, most recently used first.
+func (f *Fetcher) CacheEntries() ([]CacheEntry, error) {
+	files, err := ioutil.ReadDir(filepath.Join(f.CacheDir, entriesDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []CacheEntry
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		var entry CacheEntry
+		if err := readJSONFile(filepath.Join(f.CacheDir, entriesDir, file.Name()), &entry); err != nil {
+			return nil, fmt.Errorf("reading download cache entry %s: %s", file.Name(), err)
+		}
+		for _, path := range entry.Paths {
+			size, err := dirSize(filepath.Join(f.CacheDir, filepath.FromSlash(path)))
+			if err != nil {
+				return nil, err
+			}
+			entry.Size += size
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].LastUsed.Equal(entries[j].LastUsed) {
+			return entries[i].URI < entries[j].URI
+		}
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// PruneCache removes the entries of the download cache last used before unusedSince, as well as entries whose files
+// are missing, and then removes everything in the cache that no remaining entry refers to. It returns the removed
+// entries.
+func (f *Fetcher) PruneCache(unusedSince time.Time) ([]CacheEntry, error) {
+	entries, err := f.CacheEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []CacheEntry
+	referenced := map[string]bool{entriesDir: true}
+	for _, entry := range entries {
+		if entry.LastUsed.Before(unusedSince) || !f.pathsExist(entry.Paths) {
+			if err := os.Remove(f.entryFile(entry.URI)); err != nil {
+				return nil, err
+			}
+			removed = append(removed, entry)
+			continue
+		}
+		for _, path := range entry.Paths {
+			referenced[path] = true
+		}
+	}
+
+	if err := removeUnreferenced(f.CacheDir, "", referenced); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// removeUnreferenced removes the files and directories in dir that are not referenced. Directories containing
+// referenced paths are searched recursively.
+func removeUnreferenced(root, dir string, referenced map[string]bool) error {
+	files, err := ioutil.ReadDir(filepath.Join(root, dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range files {
+		path := strings.TrimPrefix(dir+"/"+file.Name(), "/")
+		if referenced[path] {
+			continue
+		}
+		if file.IsDir() && containsReferenced(path, referenced) {
+			if err := removeUnreferenced(root, path, referenced); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, filepath.FromSlash(path))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsReferenced(dir string, referenced map[string]bool) bool {
+	for path := range referenced {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// readCacheEntry returns the entry of the download cache for a URI, or nil if there is none.
+func (f *Fetcher) readCacheEntry(uri string) (*CacheEntry, error) {
+	var entry CacheEntry
+	if err := readJSONFile(f.entryFile(uri), &entry); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading download cache entry for %q: %s", uri, err)
+	}
+	return &entry, nil
+}
+
+// recordCacheEntry records that a URI was fetched to the given paths of the download cache, which marks it as used.
+func (f *Fetcher) recordCacheEntry(entry CacheEntry, paths ...string) error {
+	entry.Paths = nil
+	for _, path := range paths {
+		rel, err := filepath.Rel(f.CacheDir, path)
+		if err != nil {
+			return err
+		}
+		if exists, err := fileExists(path); err != nil {
+			return err
+		} else if exists {
+			entry.Paths = append(entry.Paths, filepath.ToSlash(rel))
+		}
+	}
+	entry.LastUsed = time.Now().UTC().Truncate(time.Second)
+
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(f.CacheDir, entriesDir), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.entryFile(entry.URI), contents, 0644)
+}
+
+func (f *Fetcher) entryFile(uri string) string {
+	return filepath.Join(f.CacheDir, entriesDir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(uri))))
+}
+
+func (f *Fetcher) pathsExist(paths []string) bool {
+	for _, path := range paths {
+		if exists, err := fileExists(filepath.Join(f.CacheDir, filepath.FromSlash(path))); err != nil || !exists {
+			return false
+		}
+	}
+	return len(paths) > 0
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func offlineError(uri string) error {
+	return fmt.Errorf("%q is not in the download cache and cannot be downloaded in offline mode", uri)
+}
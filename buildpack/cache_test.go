@@ -0,0 +1,128 @@
+package buildpack_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/buildpack"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestCache(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Cache", testCache, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testCache(t *testing.T, when spec.G, it spec.S) {
+	var (
+		packHome string
+		server   *ghttp.Server
+		subject  *buildpack.Fetcher
+	)
+
+	it.Before(func() {
+		var err error
+		packHome, err = ioutil.TempDir("", "cache-test")
+		h.AssertNil(t, err)
+
+		server = ghttp.NewServer()
+		server.RouteToHandler("GET", "/buildpack.tgz", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Etag", `"some-etag"`)
+			http.ServeFile(w, r, filepath.Join("testdata", "buildpack.tgz"))
+		})
+		server.RouteToHandler("GET", "/buildpack.zip", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, filepath.Join("testdata", "buildpack.zip"))
+		})
+
+		subject = buildpack.NewFetcher(&emptyLogger{}, nil, packHome)
+	})
+
+	it.After(func() {
+		server.Close()
+		os.RemoveAll(packHome)
+	})
+
+	fetch := func(path string) buildpack.Buildpack {
+		t.Helper()
+		out, err := subject.FetchBuildpack(".", buildpack.Buildpack{ID: "bp.one", URI: server.URL() + path})
+		h.AssertNil(t, err)
+		return out
+	}
+
+	when("#CacheEntries", func() {
+		it("returns nothing for an empty cache", func() {
+			entries, err := subject.CacheEntries()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(entries), 0)
+		})
+
+		it("returns the URI, ETag, size and last use of fetched buildpacks", func() {
+			before := time.Now().Add(-time.Second)
+			fetch("/buildpack.tgz")
+
+			entries, err := subject.CacheEntries()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(entries), 1)
+			h.AssertEq(t, entries[0].URI, server.URL()+"/buildpack.tgz")
+			h.AssertEq(t, entries[0].ETag, `"some-etag"`)
+			if entries[0].Size == 0 {
+				t.Fatalf("expected the size of the cached buildpack")
+			}
+			if entries[0].LastUsed.Before(before) {
+				t.Fatalf("expected last use after %s, got %s", before, entries[0].LastUsed)
+			}
+		})
+	})
+
+	when("#PruneCache", func() {
+		it("removes files that no entry refers to", func() {
+			out := fetch("/buildpack.tgz")
+			stray := filepath.Join(packHome, "dl-cache", "extract123")
+			h.AssertNil(t, os.MkdirAll(stray, 0755))
+
+			removed, err := subject.PruneCache(time.Time{})
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(removed), 0)
+
+			_, err = os.Stat(stray)
+			h.AssertEq(t, os.IsNotExist(err), true)
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
+		})
+
+		it("removes entries unused since the given time along with their files", func() {
+			tgz := fetch("/buildpack.tgz")
+			zip := fetch("/buildpack.zip")
+
+			removed, err := subject.PruneCache(time.Now().Add(time.Hour))
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(removed), 2)
+
+			entries, err := subject.CacheEntries()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(entries), 0)
+			for _, dir := range []string{tgz.Dir, zip.Dir} {
+				_, err = os.Stat(dir)
+				h.AssertEq(t, os.IsNotExist(err), true)
+			}
+		})
+
+		it("removes entries whose files are missing", func() {
+			out := fetch("/buildpack.tgz")
+			h.AssertNil(t, os.RemoveAll(out.Dir))
+
+			removed, err := subject.PruneCache(time.Time{})
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(removed), 1)
+			h.AssertEq(t, removed[0].URI, server.URL()+"/buildpack.tgz")
+		})
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type Logger interface {
@@ -18,14 +19,17 @@ type Logger interface {
 }
 
 type Fetcher struct {
-	Logger   Logger
-	CacheDir string
+	Logger       Logger
+	ImageFetcher ImageFetcher
+	CacheDir     string
+	Offline      bool // only use buildpacks that are in the download cache, without any network access
 }
 
-func NewFetcher(logger Logger, cacheDir string) *Fetcher {
+func NewFetcher(logger Logger, imageFetcher ImageFetcher, cacheDir string) *Fetcher {
 	return &Fetcher{
-		Logger:   logger,
-		CacheDir: filepath.Join(cacheDir, "dl-cache"),
+		Logger:       logger,
+		ImageFetcher: imageFetcher,
+		CacheDir:     filepath.Join(cacheDir, "dl-cache"),
 	}
 }
 
@@ -35,6 +39,7 @@ func (f *Fetcher) FetchBuildpack(localSearchPath string, bp Buildpack) (out Buil
 		URI:     bp.URI,
 		Latest:  bp.Latest,
 		Version: bp.Version,
+		SHA256:  bp.SHA256,
 	}
 
 	bpURL, err := url.Parse(bp.URI)
@@ -42,11 +47,36 @@ func (f *Fetcher) FetchBuildpack(localSearchPath string, bp Buildpack) (out Buil
 		return out, err
 	}
 
+	expected, err := expectedDigest(bp, bpURL)
+	if err != nil {
+		return out, err
+	}
+
 	switch bpURL.Scheme {
 	case "", "file":
-		out.Dir, err = f.handleFile(localSearchPath, bpURL)
+		out.Dir, out.SourceDigest, err = f.handleFile(localSearchPath, bp, bpURL, expected)
 	case "http", "https":
-		out.Dir, err = f.handleHTTP(bp)
+		var digest string
+		if digest, err = f.handleHTTP(bp, bpURL, expected); err == nil {
+			out.SourceDigest = "sha256:" + digest
+			out.Dir, err = archiveBuildpackDir(f.digestDir(digest), bp)
+		}
+	case "git+https", "git+http", "git+ssh", "git+file":
+		if expected != "" {
+			return out, unverifiableDigestError(bp.URI)
+		}
+		out.Dir, out.Commit, err = f.handleGit(bp, bpURL)
+		out.SourceDigest = out.Commit
+	case "docker":
+		if expected != "" {
+			return out, unverifiableDigestError(bp.URI)
+		}
+		out.Dir, out.SourceDigest, err = f.handleImage(bp)
+	case "oci":
+		if expected != "" {
+			return out, unverifiableDigestError(bp.URI)
+		}
+		out.Dir, out.SourceDigest, err = f.handleOCI(localSearchPath, bp, bpURL)
 	default:
 		return out, fmt.Errorf("unsupported protocol in URI %q", bp.URI)
 	}
@@ -54,73 +84,244 @@ func (f *Fetcher) FetchBuildpack(localSearchPath string, bp Buildpack) (out Buil
 	return out, err
 }
 
-func (f *Fetcher) handleFile(localSearchPath string, bpURL *url.URL) (string, error) {
+// handleFile returns the directory of a local buildpack, extracting it first when it is an archive, along with the
+// digest of the archive. An archive is verified against the expected digest (if any) before it is extracted; a directory
+// has no digest, and cannot be pinned to one.
+func (f *Fetcher) handleFile(localSearchPath string, bp Buildpack, bpURL *url.URL, expected string) (string, string, error) {
 	path := bpURL.Path
 
 	if !bpURL.IsAbs() && !filepath.IsAbs(path) {
 		path = filepath.Join(localSearchPath, path)
 	}
 
-	if filepath.Ext(path) != ".tgz" {
-		return path, nil
+	// anything but a file is left to be read as a buildpack directory
+	if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+		if expected != "" {
+			return "", "", unverifiableDigestError(bp.URI)
+		}
+		return path, "", nil
 	}
 
-	file, err := os.Open(path)
+	digest, err := fileDigest(path)
 	if err != nil {
-		return "", errors.Wrapf(err, "could not open file to untar: %q", path)
+		return "", "", err
+	}
+	if err := verifyDigest(bp.URI, expected, digest); err != nil {
+		return "", "", err
 	}
-	defer file.Close()
 
 	tmpDir, err := ioutil.TempDir("", "")
 	if err != nil {
-		return "", fmt.Errorf(`failed to create temporary directory: %s`, err)
+		return "", "", fmt.Errorf(`failed to create temporary directory: %s`, err)
 	}
 
-	if err = archive.ExtractTarGZ(file, tmpDir); err != nil {
-		return "", err
+	if err = archive.ExtractFile(path, tmpDir); err != nil {
+		return "", "", err
 	}
 
-	return tmpDir, nil
+	dir, err := archiveBuildpackDir(tmpDir, bp)
+	return dir, "sha256:" + digest, err
 }
 
-func (f *Fetcher) handleHTTP(bp Buildpack) (string, error) {
-	bpCache := filepath.Join(f.CacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(bp.URI))))
-	if err := os.MkdirAll(bpCache, 0744); err != nil {
-		return "", err
+// archiveBuildpackDir returns the directory of the buildpack in an extracted archive. An archive either contains a
+// single buildpack at its root, or several buildpacks in <id>/<version> directories, of which the one with the ID and
+// version of bp is selected.
+func archiveBuildpackDir(dir string, bp Buildpack) (string, error) {
+	if exists, err := fileExists(filepath.Join(dir, "buildpack.toml")); err != nil || exists {
+		return dir, err
 	}
+	return findBuildpackDir(dir, bp)
+}
 
-	etagFile := bpCache + ".etag"
-	etagExists, err := fileExists(etagFile)
+// handleHTTP downloads a buildpack archive into the download cache, which is keyed by the sha256 digest of the archive,
+// and returns that digest.
+// When the buildpack is pinned to a digest, the archive is verified before it is extracted, and a cached archive with
+// that digest is used without downloading it again. In offline mode, the archive last downloaded from the URI is used.
+func (f *Fetcher) handleHTTP(bp Buildpack, bpURL *url.URL, expected string) (string, error) {
+	entry, err := f.readCacheEntry(bp.URI)
 	if err != nil {
 		return "", err
 	}
+	if entry == nil {
+		entry = &CacheEntry{URI: bp.URI}
+	}
 
-	etag := ""
-	if etagExists {
-		bytes, err := ioutil.ReadFile(etagFile)
+	if expected != "" {
+		dir, err := f.cachedDigestDir(expected)
+		if err != nil {
+			return "", err
+		} else if dir != "" {
+			f.Logger.Verbose("Using cached version of %q\n", bp.URI)
+			if entry.Digest != expected {
+				// the ETag refers to another archive
+				entry.ETag, entry.Digest = "", expected
+			}
+			return f.useCachedDigest(bp.URI, *entry, expected)
+		}
+	}
+
+	cached := false
+	if entry.Digest != "" {
+		dir, err := f.cachedDigestDir(entry.Digest)
 		if err != nil {
 			return "", err
 		}
-		etag = string(bytes)
+		cached = dir != ""
 	}
 
+	if f.Offline {
+		// a pinned archive that is not cached cannot be the one last downloaded from the URI either
+		if !cached || expected != "" {
+			return "", offlineError(bp.URI)
+		}
+		f.Logger.Verbose("Using cached version of %q\n", bp.URI)
+		return f.useCachedDigest(bp.URI, *entry, expected)
+	}
+
+	// the ETag is only sent when the archive it refers to is still in the cache
+	etag := ""
+	if cached {
+		etag = entry.ETag
+	}
+
+	if err := os.MkdirAll(f.CacheDir, 0755); err != nil {
+		return "", err
+	}
 	reader, etag, err := f.downloadAsStream(bp.URI, etag)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to download from %q", bp.URI)
 	} else if reader == nil {
-		return bpCache, nil
+		return f.useCachedDigest(bp.URI, *entry, expected)
 	}
 	defer reader.Close()
 
-	if err = archive.ExtractTarGZ(reader, bpCache); err != nil {
+	digest, err := f.extractVerified(bp.URI, reader, expected)
+	if err != nil {
+		return "", err
+	}
+
+	entry.ETag = etag
+	entry.Digest = digest
+	if err := f.recordCacheEntry(*entry, f.digestDir(digest)); err != nil {
 		return "", err
 	}
+	return digest, nil
+}
 
-	if err = ioutil.WriteFile(etagFile, []byte(etag), 0744); err != nil {
+// useCachedDigest uses the archive last downloaded from a URI, which must have the expected digest (if any), and
+// returns its digest.
+func (f *Fetcher) useCachedDigest(uri string, entry CacheEntry, expected string) (string, error) {
+	if err := verifyDigest(uri, expected, entry.Digest); err != nil {
+		return "", err
+	}
+	if err := f.recordCacheEntry(entry, f.digestDir(entry.Digest)); err != nil {
 		return "", err
 	}
+	return entry.Digest, nil
+}
 
-	return bpCache, nil
+// cachedDigestDir returns the directory of the cached archive with a digest, or an empty string if it is not cached.
+func (f *Fetcher) cachedDigestDir(digest string) (string, error) {
+	dir := f.digestDir(digest)
+	if exists, err := fileExists(dir); err != nil || !exists {
+		return "", err
+	}
+	return dir, nil
+}
+
+// extractVerified saves a downloaded archive to a temporary file while computing its digest, checks the digest
+// against the expected one (if any) and only then extracts the archive into the cache.
+func (f *Fetcher) extractVerified(uri string, reader io.Reader, expected string) (string, error) {
+	tmpFile, err := ioutil.TempFile(f.CacheDir, "download")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmpFile, hasher), reader); err != nil {
+		return "", errors.Wrapf(err, "failed to download from %q", uri)
+	}
+	digest := fmt.Sprintf("%x", hasher.Sum(nil))
+	if err := verifyDigest(uri, expected, digest); err != nil {
+		return "", err
+	}
+
+	dir := f.digestDir(digest)
+	if exists, err := fileExists(dir); err != nil || exists {
+		return digest, err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return "", err
+	}
+	tmpDir, err := ioutil.TempDir(f.CacheDir, "extract")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err = archive.ExtractFile(tmpFile.Name(), tmpDir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+func (f *Fetcher) digestDir(digest string) string {
+	return filepath.Join(f.CacheDir, "sha256-"+digest)
+}
+
+// expectedDigest returns the sha256 digest a buildpack is pinned to, given either by the sha256 key in builder.toml or
+// by a #sha256=<digest> fragment on its URI.
+func expectedDigest(bp Buildpack, bpURL *url.URL) (string, error) {
+	fromTOML := normalizeDigest(bp.SHA256)
+	fromURI := ""
+	if strings.HasPrefix(bpURL.Fragment, "sha256=") {
+		fromURI = normalizeDigest(strings.TrimPrefix(bpURL.Fragment, "sha256="))
+	}
+
+	if fromTOML != "" && fromURI != "" && fromTOML != fromURI {
+		return "", fmt.Errorf("conflicting sha256 digests for %q: sha256:%s and sha256:%s", bp.URI, fromTOML, fromURI)
+	}
+	if fromTOML != "" {
+		return fromTOML, nil
+	}
+	return fromURI, nil
+}
+
+func normalizeDigest(digest string) string {
+	return strings.ToLower(strings.TrimPrefix(digest, "sha256:"))
+}
+
+// unverifiableDigestError is returned for a buildpack pinned to a digest that is not a buildpack archive.
+func unverifiableDigestError(uri string) error {
+	return fmt.Errorf("cannot verify the sha256 digest of %q: only buildpack archives can be pinned to a digest", uri)
+}
+
+// fileDigest returns the sha256 digest of a file.
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
+
+func verifyDigest(uri, expected, actual string) error {
+	if expected != "" && expected != actual {
+		return fmt.Errorf("sha256 mismatch for %q: expected sha256:%s, got sha256:%s", uri, expected, actual)
+	}
+	return nil
 }
 
 func (f *Fetcher) downloadAsStream(uri string, etag string) (io.ReadCloser, string, error) {
@@ -1,19 +1,29 @@
 package buildpack_test
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
 	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega/ghttp"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
+	"github.com/buildpack/pack/archive"
 	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/mocks"
 	h "github.com/buildpack/pack/testhelpers"
 )
 
@@ -35,23 +45,29 @@ func (e *emptyLogger) Verbose(format string, a ...interface{}) {
 func testBuildpackFetcher(t *testing.T, when spec.G, it spec.S) {
 	when("#FetchBuildpack", func() {
 		var (
-			err      error
-			tmpDir   string
-			cacheDir string
-			subject  *buildpack.Fetcher
+			err            error
+			tmpDir         string
+			cacheDir       string
+			mockController *gomock.Controller
+			mockFetcher    *mocks.MockFetcher
+			subject        *buildpack.Fetcher
 		)
 
 		it.Before(func() {
+			mockController = gomock.NewController(t)
+			mockFetcher = mocks.NewMockFetcher(mockController)
+
 			tmpDir, err = ioutil.TempDir("", "")
 			h.AssertNil(t, err)
 
 			cacheDir, err = ioutil.TempDir("", "")
 			h.AssertNil(t, err)
 
-			subject = buildpack.NewFetcher(&emptyLogger{}, cacheDir)
+			subject = buildpack.NewFetcher(&emptyLogger{}, mockFetcher, cacheDir)
 		})
 
 		it.After(func() {
+			mockController.Finish()
 			os.RemoveAll(tmpDir)
 			os.RemoveAll(cacheDir)
 		})
@@ -82,6 +98,99 @@ func testBuildpackFetcher(t *testing.T, when spec.G, it spec.S) {
 			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from an archive\n")
 		})
 
+		it("fetches from a tar regardless of its extension", func() {
+			tarFile := filepath.Join(tmpDir, "buildpack.download")
+			h.AssertNil(t, archive.CreateTar(tarFile, filepath.Join("testdata", "buildpack"), "/", 0, 0))
+
+			out, err := subject.FetchBuildpack(".", buildpack.Buildpack{ID: "bp.one", URI: tarFile})
+			h.AssertNil(t, err)
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from a directory\n")
+		})
+
+		when("an archive contains several versions of a buildpack", func() {
+			var tarFile string
+
+			it.Before(func() {
+				for _, version := range []string{"1.0.0", "2.0.0"} {
+					dir := filepath.Join(tmpDir, "buildpacks", "bp.one", version)
+					h.AssertNil(t, os.MkdirAll(dir, 0755))
+					h.AssertNil(t, ioutil.WriteFile(filepath.Join(dir, "buildpack.toml"), []byte(fmt.Sprintf(`
+[buildpack]
+id = "bp.one"
+version = %q
+`, version)), 0644))
+				}
+				tarFile = filepath.Join(tmpDir, "buildpacks.tar")
+				h.AssertNil(t, archive.CreateTar(tarFile, filepath.Join(tmpDir, "buildpacks"), "/", 0, 0))
+			})
+
+			it("selects the buildpack with the given version", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{ID: "bp.one", Version: "2.0.0", URI: tarFile})
+				h.AssertNil(t, err)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "buildpack.toml", "\n[buildpack]\nid = \"bp.one\"\nversion = \"2.0.0\"\n")
+			})
+
+			it("fails without a version to select one of them", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{ID: "bp.one", URI: tarFile})
+				h.AssertError(t, err, fmt.Sprintf(`found several buildpacks matching "bp.one" in %q (bp.one@1.0.0, bp.one@2.0.0)`, tarFile))
+			})
+
+			it("fails for a version that is not in the archive", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{ID: "bp.one", Version: "3.0.0", URI: tarFile})
+				h.AssertError(t, err, fmt.Sprintf(`could not find buildpack "bp.one@3.0.0" in %q`, tarFile))
+			})
+		})
+
+		when("a local buildpack is pinned to a sha256 digest", func() {
+			var digest string
+
+			it.Before(func() {
+				contents, err := ioutil.ReadFile(filepath.Join("testdata", "buildpack.tgz"))
+				h.AssertNil(t, err)
+				digest = fmt.Sprintf("%x", sha256.Sum256(contents))
+			})
+
+			it("fetches the archive when the digest matches", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:     "bp.one",
+					URI:    filepath.Join("testdata", "buildpack.tgz"),
+					SHA256: "sha256:" + digest,
+				})
+				h.AssertNil(t, err)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from an archive\n")
+			})
+
+			it("records the digest of the archive as the source digest", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "bp.one",
+					URI: filepath.Join("testdata", "buildpack.tgz"),
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.SourceDigest, "sha256:"+digest)
+			})
+
+			it("fails when the digest of the archive does not match", func() {
+				absPath, err := filepath.Abs(filepath.Join("testdata", "buildpack.tgz"))
+				h.AssertNil(t, err)
+
+				wrong := fmt.Sprintf("%x", sha256.Sum256([]byte("something else")))
+				_, err = subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "bp.one",
+					URI: "file://" + absPath + "#sha256=" + wrong,
+				})
+				h.AssertError(t, err, fmt.Sprintf("expected sha256:%s, got sha256:%s", wrong, digest))
+			})
+
+			it("fails for a directory, which has no digest", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:     "bp.one",
+					URI:    filepath.Join("testdata", "buildpack"),
+					SHA256: digest,
+				})
+				h.AssertError(t, err, "only buildpack archives can be pinned to a digest")
+			})
+		})
+
 		it("fetches from an absolute directory", func() {
 			absPath, err := filepath.Abs(filepath.Join("testdata", "buildpack"))
 			h.AssertNil(t, err)
@@ -164,5 +273,420 @@ func testBuildpackFetcher(t *testing.T, when spec.G, it spec.S) {
 			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
 			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from an archive\n")
 		})
+
+		it("fetches from a 'http(s)://' URI zip containing the buildpack in a directory", func() {
+			server := ghttp.NewServer()
+			server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				http.ServeFile(w, r, filepath.Join("testdata", "buildpack.zip"))
+			})
+			defer server.Close()
+
+			out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+				ID:  "bp.one",
+				URI: server.URL() + "/releases/download/latest",
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, filepath.Base(out.Dir), "buildpack-1.0")
+			h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from a zip\n")
+			fi, err := os.Stat(filepath.Join(out.Dir, "bin", "detect"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, fi.Mode().Perm(), os.FileMode(0755))
+		})
+
+		when("the 'http(s)://' URI is pinned to a sha256 digest", func() {
+			var (
+				server *ghttp.Server
+				digest string
+			)
+
+			it.Before(func() {
+				contents, err := ioutil.ReadFile(filepath.Join("testdata", "buildpack.tgz"))
+				h.AssertNil(t, err)
+				digest = fmt.Sprintf("%x", sha256.Sum256(contents))
+
+				server = ghttp.NewServer()
+				server.RouteToHandler("GET", regexp.MustCompile(`/.*\.tgz`), func(w http.ResponseWriter, r *http.Request) {
+					http.ServeFile(w, r, filepath.Join("testdata", "buildpack.tgz"))
+				})
+			})
+
+			it.After(func() {
+				server.Close()
+			})
+
+			it("fetches the buildpack when the digest matches", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:     "bp.one",
+					URI:    server.URL() + "/buildpack.tgz",
+					SHA256: digest,
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.Dir, filepath.Join(cacheDir, "dl-cache", "sha256-"+digest))
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from an archive\n")
+			})
+
+			it("accepts the digest as a URI fragment", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "bp.one",
+					URI: server.URL() + "/buildpack.tgz#sha256=" + digest,
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.Dir, filepath.Join(cacheDir, "dl-cache", "sha256-"+digest))
+			})
+
+			it("uses the cached archive without downloading it again", func() {
+				bp := buildpack.Buildpack{ID: "bp.one", URI: server.URL() + "/buildpack.tgz", SHA256: "sha256:" + digest}
+				_, err := subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+				_, err = subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(server.ReceivedRequests()), 1)
+			})
+
+			it("shares the cache between URIs with the same content", func() {
+				first, err := subject.FetchBuildpack(".", buildpack.Buildpack{ID: "bp.one", URI: server.URL() + "/buildpack.tgz"})
+				h.AssertNil(t, err)
+				second, err := subject.FetchBuildpack(".", buildpack.Buildpack{ID: "bp.one", URI: server.URL() + "/mirror/buildpack.tgz"})
+				h.AssertNil(t, err)
+				h.AssertEq(t, second.Dir, first.Dir)
+			})
+
+			it("fails without extracting the archive when the digest does not match", func() {
+				wrong := fmt.Sprintf("%x", sha256.Sum256([]byte("something else")))
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:     "bp.one",
+					URI:    server.URL() + "/buildpack.tgz",
+					SHA256: wrong,
+				})
+				h.AssertError(t, err, fmt.Sprintf(`sha256 mismatch for "%s/buildpack.tgz": expected sha256:%s, got sha256:%s`, server.URL(), wrong, digest))
+
+				_, err = os.Stat(filepath.Join(cacheDir, "dl-cache", "sha256-"+digest))
+				h.AssertEq(t, os.IsNotExist(err), true)
+			})
+
+			it("fails when builder.toml and the URI fragment disagree", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:     "bp.one",
+					URI:    server.URL() + "/buildpack.tgz#sha256=" + digest,
+					SHA256: "0123",
+				})
+				h.AssertError(t, err, "conflicting sha256 digests")
+			})
+		})
+
+		when("the 'http(s)://' URI is in the download cache", func() {
+			var (
+				server *ghttp.Server
+				bp     buildpack.Buildpack
+			)
+
+			it.Before(func() {
+				server = ghttp.NewServer()
+				server.RouteToHandler("GET", regexp.MustCompile(`/.*\.tgz`), func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Etag", `"some-etag"`)
+					http.ServeFile(w, r, filepath.Join("testdata", "buildpack.tgz"))
+				})
+				bp = buildpack.Buildpack{ID: "bp.one", URI: server.URL() + "/buildpack.tgz"}
+			})
+
+			it.After(func() {
+				server.Close()
+			})
+
+			it("makes a conditional request with the ETag of the cached archive", func() {
+				first, err := subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+				second, err := subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+				h.AssertEq(t, second.Dir, first.Dir)
+				h.AssertEq(t, len(server.ReceivedRequests()), 2)
+				h.AssertEq(t, server.ReceivedRequests()[1].Header.Get("If-None-Match"), `"some-etag"`)
+			})
+
+			it("uses the cached archive without any request in offline mode", func() {
+				online, err := subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+
+				subject.Offline = true
+				offline, err := subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+				h.AssertEq(t, offline.Dir, online.Dir)
+				h.AssertEq(t, len(server.ReceivedRequests()), 1)
+			})
+
+			it("fails in offline mode for a URI that is not in the download cache", func() {
+				subject.Offline = true
+				_, err := subject.FetchBuildpack(".", bp)
+				h.AssertError(t, err, fmt.Sprintf(`"%s/buildpack.tgz" is not in the download cache and cannot be downloaded in offline mode`, server.URL()))
+				h.AssertEq(t, len(server.ReceivedRequests()), 0)
+			})
+		})
+
+		when("the URI is a 'docker://' image", func() {
+			var layerTar string
+
+			it.Before(func() {
+				layerTar = filepath.Join(tmpDir, "layer.tar")
+				h.AssertNil(t, archive.CreateTar(layerTar, filepath.Join("testdata", "buildpack"), "/buildpacks/some-buildpack-id/1.2.3", 0, 0))
+
+				bpImage := imgtest.NewFakeImage(t, "some/buildpack", "sha256:"+imgtest.ComputeSHA256ForFile(t, layerTar), "")
+				h.AssertNil(t, bpImage.AddLayer(layerTar))
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "registry.example.com/some/buildpack:1.2.3", gomock.Any()).
+					Return(bpImage, nil).AnyTimes()
+			})
+
+			it("fetches the buildpack from the top layer of the image", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "some-buildpack-id",
+					URI: "docker://registry.example.com/some/buildpack:1.2.3",
+				})
+				h.AssertNil(t, err)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from a directory\n")
+			})
+
+			it("extracts each layer only once", func() {
+				bp := buildpack.Buildpack{ID: "some-buildpack-id", URI: "docker://registry.example.com/some/buildpack:1.2.3"}
+				first, err := subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+
+				h.AssertNil(t, os.Remove(layerTar))
+				second, err := subject.FetchBuildpack(".", bp)
+				h.AssertNil(t, err)
+				h.AssertEq(t, second.Dir, first.Dir)
+			})
+
+			it("uses the image in the daemon without pulling it in offline mode", func() {
+				bpImage := imgtest.NewFakeImage(t, "some/buildpack", "sha256:"+imgtest.ComputeSHA256ForFile(t, layerTar), "")
+				h.AssertNil(t, bpImage.AddLayer(layerTar))
+				mockFetcher.EXPECT().FetchLocalImage("registry.example.com/some/buildpack:1.2.3").Return(bpImage, nil)
+
+				subject.Offline = true
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "some-buildpack-id",
+					URI: "docker://registry.example.com/some/buildpack:1.2.3",
+				})
+				h.AssertNil(t, err)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/detect", "I come from a directory\n")
+			})
+
+			it("fails when the image is pinned to a sha256 digest", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:     "some-buildpack-id",
+					URI:    "docker://registry.example.com/some/buildpack:1.2.3",
+					SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte("something else"))),
+				})
+				h.AssertError(t, err, `cannot verify the sha256 digest of "docker://registry.example.com/some/buildpack:1.2.3"`)
+			})
+
+			it("returns an error when the image does not contain the buildpack", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "bp.other",
+					URI: "docker://registry.example.com/some/buildpack:1.2.3",
+				})
+				h.AssertError(t, err, `could not find buildpack "bp.other" in "docker://registry.example.com/some/buildpack:1.2.3"`)
+			})
+		})
+
+		when("the URI is an 'oci:' layout", func() {
+			it.Before(func() {
+				createOCILayout(t, filepath.Join(tmpDir, "layout"), filepath.Join("testdata", "buildpack"), "1.2.3")
+			})
+
+			it("fetches the buildpack from the top layer of the image", func() {
+				out, err := subject.FetchBuildpack(tmpDir, buildpack.Buildpack{
+					ID:  "some-buildpack-id",
+					URI: "oci:layout",
+				})
+				h.AssertNil(t, err)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from a directory\n")
+			})
+
+			it("selects a manifest by ref name", func() {
+				out, err := subject.FetchBuildpack(tmpDir, buildpack.Buildpack{
+					ID:  "some-buildpack-id",
+					URI: "oci:layout#1.2.3",
+				})
+				h.AssertNil(t, err)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from a directory\n")
+			})
+
+			it("fails when the layout is pinned to a sha256 digest", func() {
+				_, err := subject.FetchBuildpack(tmpDir, buildpack.Buildpack{
+					ID:     "some-buildpack-id",
+					URI:    "oci:layout",
+					SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte("something else"))),
+				})
+				h.AssertError(t, err, `cannot verify the sha256 digest of "oci:layout"`)
+			})
+
+			it("returns an error for an unknown ref name", func() {
+				_, err := subject.FetchBuildpack(tmpDir, buildpack.Buildpack{
+					ID:  "some-buildpack-id",
+					URI: "oci:layout#9.9.9",
+				})
+				h.AssertError(t, err, `no manifest with ref name "9.9.9"`)
+			})
+		})
+
+		when("the URI is a 'git+' repository", func() {
+			var (
+				repoDir             string
+				firstCommit, second string
+			)
+
+			it.Before(func() {
+				repoDir = filepath.Join(tmpDir, "repo")
+				h.AssertNil(t, os.MkdirAll(filepath.Join(repoDir, "buildpacks", "some-buildpack"), 0755))
+				git(t, repoDir, "init", "--quiet")
+				h.RecursiveCopy(t, filepath.Join("testdata", "buildpack"), filepath.Join(repoDir, "buildpacks", "some-buildpack"))
+				git(t, repoDir, "add", ".")
+				git(t, repoDir, "commit", "--quiet", "-m", "first")
+				git(t, repoDir, "tag", "v1")
+				firstCommit = git(t, repoDir, "rev-parse", "HEAD")
+
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(repoDir, "buildpacks", "some-buildpack", "bin", "build"), []byte("second build\n"), 0755))
+				h.AssertNil(t, os.Chmod(filepath.Join(repoDir, "buildpacks", "some-buildpack", "bin", "build"), 0755))
+				git(t, repoDir, "commit", "--quiet", "-am", "second")
+				second = git(t, repoDir, "rev-parse", "HEAD")
+			})
+
+			it("fetches the buildpack from HEAD of the repository", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					URI: "git+file://" + repoDir + "?subdir=buildpacks/some-buildpack",
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.Commit, second)
+				h.AssertEq(t, out.SourceDigest, second)
+				h.AssertEq(t, out.Dir, filepath.Join(cacheDir, "dl-cache", "git-"+second, "buildpacks", "some-buildpack"))
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "second build\n")
+				fi, err := os.Stat(filepath.Join(out.Dir, "bin", "build"))
+				h.AssertNil(t, err)
+				h.AssertEq(t, fi.Mode().Perm(), os.FileMode(0755))
+			})
+
+			it("fetches the buildpack at a tag", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					URI: "git+file://" + repoDir + "?subdir=buildpacks/some-buildpack#v1",
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.Commit, firstCommit)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from a directory\n")
+			})
+
+			it("finds the buildpack by ID without a subdir", func() {
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					ID:  "some-buildpack-id",
+					URI: "git+file://" + repoDir + "#" + firstCommit,
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.Dir, filepath.Join(cacheDir, "dl-cache", "git-"+firstCommit, "buildpacks", "some-buildpack"))
+			})
+
+			it("uses a cached commit without fetching the repository again", func() {
+				uri := "git+file://" + repoDir + "?subdir=buildpacks/some-buildpack#" + firstCommit
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: uri})
+				h.AssertNil(t, err)
+
+				h.AssertNil(t, os.RemoveAll(repoDir))
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: uri})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.Commit, firstCommit)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "I come from a directory\n")
+			})
+
+			it("uses the commit last fetched for the URI in offline mode", func() {
+				uri := "git+file://" + repoDir + "?subdir=buildpacks/some-buildpack"
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: uri})
+				h.AssertNil(t, err)
+
+				h.AssertNil(t, os.RemoveAll(repoDir))
+				subject.Offline = true
+				out, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: uri})
+				h.AssertNil(t, err)
+				h.AssertEq(t, out.Commit, second)
+				h.AssertDirContainsFileWithContents(t, out.Dir, "bin/build", "second build\n")
+			})
+
+			it("fails in offline mode for a URI that was never fetched", func() {
+				subject.Offline = true
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{URI: "git+file://" + repoDir + "#v1"})
+				h.AssertError(t, err, fmt.Sprintf(`"git+file://%s#v1" is not in the download cache and cannot be downloaded in offline mode`, repoDir))
+			})
+
+			it("fails when the repository is pinned to a sha256 digest", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					URI:    "git+file://" + repoDir + "#v1",
+					SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte("something else"))),
+				})
+				h.AssertError(t, err, "only buildpack archives can be pinned to a digest")
+			})
+
+			it("returns an error for an unknown ref", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					URI: "git+file://" + repoDir + "#v9",
+				})
+				h.AssertError(t, err, fmt.Sprintf(`could not find "v9" in git repository "file://%s"`, repoDir))
+			})
+
+			it("returns an error for a subdir outside the repository", func() {
+				_, err := subject.FetchBuildpack(".", buildpack.Buildpack{
+					URI: "git+file://" + repoDir + "?subdir=../other",
+				})
+				h.AssertError(t, err, "must be inside the repository")
+			})
+		})
 	})
 }
+
+// git runs a git command in dir and returns its trimmed output.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Some User", "-c", "user.email=user@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s: %s", args[0], err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// createOCILayout writes a single-layer image containing the buildpack in srcDir to an OCI image layout at dir.
+func createOCILayout(t *testing.T, dir, srcDir, refName string) {
+	t.Helper()
+	blobsDir := filepath.Join(dir, "blobs", "sha256")
+	h.AssertNil(t, os.MkdirAll(blobsDir, 0755))
+
+	layerTar := filepath.Join(dir, "layer.tar")
+	h.AssertNil(t, archive.CreateTar(layerTar, srcDir, "/buildpacks/some-buildpack-id/"+refName, 0, 0))
+	layerSHA := imgtest.ComputeSHA256ForFile(t, layerTar)
+	h.AssertNil(t, os.Rename(layerTar, filepath.Join(blobsDir, layerSHA)))
+
+	writeBlob := func(v interface{}) string {
+		contents, err := json.Marshal(v)
+		h.AssertNil(t, err)
+		path := filepath.Join(dir, "blob.json")
+		h.AssertNil(t, ioutil.WriteFile(path, contents, 0644))
+		sha := imgtest.ComputeSHA256ForFile(t, path)
+		h.AssertNil(t, os.Rename(path, filepath.Join(blobsDir, sha)))
+		return "sha256:" + sha
+	}
+
+	manifestDigest := writeBlob(map[string]interface{}{
+		"schemaVersion": 2,
+		"layers": []map[string]interface{}{
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": "sha256:" + layerSHA},
+		},
+	})
+
+	index := fmt.Sprintf(`{
+  "schemaVersion": 2,
+  "manifests": [
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "%s",
+      "annotations": {"org.opencontainers.image.ref.name": "%s"}
+    }
+  ]
+}`, manifestDigest, refName)
+	h.AssertNil(t, ioutil.WriteFile(filepath.Join(dir, "index.json"), []byte(index), 0644))
+}
@@ -0,0 +1,152 @@
+package buildpack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+)
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// handleGit fetches a buildpack from a git repository given by a git+<transport> URI. The fragment of the URI selects a
+// branch, tag or commit (HEAD of the repository by default), and a subdir query parameter selects the directory of the
+// repository containing the buildpack. Repositories are mirrored in the download cache, and the files of each commit
+// are checked out once into a directory keyed by the commit. In offline mode, the commit last fetched for the URI is
+// used unless the URI names a cached commit.
+func (f *Fetcher) handleGit(bp Buildpack, bpURL *url.URL) (dir, commit string, err error) {
+	repoURL := *bpURL
+	repoURL.Scheme = strings.TrimPrefix(bpURL.Scheme, "git+")
+	repoURL.RawQuery = ""
+	repoURL.Fragment = ""
+	repo := repoURL.String()
+
+	subdir := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(bpURL.Query().Get("subdir"), "/")))
+	if subdir == ".." || strings.HasPrefix(subdir, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("subdir of %q must be inside the repository", bp.URI)
+	}
+
+	mirror := filepath.Join(f.CacheDir, "git", fmt.Sprintf("%x", sha256.Sum256([]byte(repo))))
+	ref := bpURL.Fragment
+	if commitPattern.MatchString(ref) {
+		if exists, err := fileExists(f.commitDir(ref)); err != nil {
+			return "", "", err
+		} else if exists {
+			f.Logger.Verbose("Using cached commit %s of %q\n", ref, repo)
+			return f.gitBuildpackDir(bp, mirror, ref, subdir)
+		}
+	}
+
+	if f.Offline {
+		// the commit last fetched for the URI is used, as branches and tags cannot be resolved without fetching
+		entry, err := f.readCacheEntry(bp.URI)
+		if err != nil {
+			return "", "", err
+		}
+		if entry == nil || entry.Commit == "" {
+			return "", "", offlineError(bp.URI)
+		}
+		if exists, err := fileExists(f.commitDir(entry.Commit)); err != nil {
+			return "", "", err
+		} else if !exists {
+			return "", "", offlineError(bp.URI)
+		}
+		f.Logger.Verbose("Using cached commit %s of %q\n", entry.Commit, repo)
+		return f.gitBuildpackDir(bp, mirror, entry.Commit, subdir)
+	}
+
+	if exists, err := fileExists(mirror); err != nil {
+		return "", "", err
+	} else if !exists {
+		if _, err := runGit("", "init", "--quiet", "--bare", mirror); err != nil {
+			return "", "", err
+		}
+	}
+
+	f.Logger.Verbose("Fetching %q\n", repo)
+	if _, err := runGit(mirror, "fetch", "--quiet", "--force", "--tags", repo,
+		"+HEAD:refs/remotes/origin/HEAD", "+refs/heads/*:refs/heads/*"); err != nil {
+		return "", "", errors.Wrapf(err, "failed to fetch from %q", repo)
+	}
+
+	if ref == "" {
+		ref = "refs/remotes/origin/HEAD"
+	}
+	out, err := runGit(mirror, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", "", fmt.Errorf("could not find %q in git repository %q", bpURL.Fragment, repo)
+	}
+	commit = strings.TrimSpace(string(out))
+
+	if err := f.checkoutCommit(mirror, commit); err != nil {
+		return "", "", errors.Wrapf(err, "failed to check out commit %s of %q", commit, repo)
+	}
+	return f.gitBuildpackDir(bp, mirror, commit, subdir)
+}
+
+// checkoutCommit extracts the files of a commit into its directory in the cache, unless they already are.
+func (f *Fetcher) checkoutCommit(mirror, commit string) error {
+	dir := f.commitDir(commit)
+	if exists, err := fileExists(dir); err != nil || exists {
+		return err
+	}
+
+	out, err := runGit(mirror, "archive", "--format=tar", commit)
+	if err != nil {
+		return err
+	}
+	tmpDir, err := ioutil.TempDir(f.CacheDir, "checkout")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := archive.ExtractTar(bytes.NewReader(out), tmpDir); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dir)
+}
+
+// gitBuildpackDir records the commit fetched for the URI of the buildpack in the download cache, and returns the
+// directory of the buildpack in its checkout.
+func (f *Fetcher) gitBuildpackDir(bp Buildpack, mirror, commit, subdir string) (string, string, error) {
+	if err := f.recordCacheEntry(CacheEntry{URI: bp.URI, Commit: commit}, f.commitDir(commit), mirror); err != nil {
+		return "", "", err
+	}
+	dir, err := archiveBuildpackDir(filepath.Join(f.commitDir(commit), subdir), bp)
+	return dir, commit, err
+}
+
+func (f *Fetcher) commitDir(commit string) string {
+	return filepath.Join(f.CacheDir, "git-"+commit)
+}
+
+// runGit runs a git command, in the repository gitDir unless it is empty, and returns its output.
+func runGit(gitDir, command string, args ...string) ([]byte, error) {
+	args = append([]string{command}, args...)
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", command, msg)
+		}
+		return nil, errors.Wrapf(err, "git %s", command)
+	}
+	return out, nil
+}
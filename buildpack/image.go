@@ -0,0 +1,249 @@
+package buildpack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+)
+
+type ImageFetcher interface {
+	FetchUpdatedLocalImage(context.Context, string, io.Writer) (image.Image, error)
+	FetchLocalImage(string) (image.Image, error)
+}
+
+const ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// handleImage pulls the image named by a docker:// URI through the daemon and extracts its top layer, which is
+// expected to contain the buildpack, and returns the directory of the buildpack along with the digest of the layer. In
+// offline mode, the image already in the daemon is used without pulling it.
+func (f *Fetcher) handleImage(bp Buildpack) (string, string, error) {
+	if f.ImageFetcher == nil {
+		return "", "", fmt.Errorf("cannot fetch buildpack image %q without an image fetcher", bp.URI)
+	}
+	imageName := strings.TrimPrefix(bp.URI, "docker://")
+
+	var img image.Image
+	var err error
+	if f.Offline {
+		img, err = f.ImageFetcher.FetchLocalImage(imageName)
+	} else {
+		f.Logger.Verbose("Pulling buildpack image %q\n", imageName)
+		img, err = f.ImageFetcher.FetchUpdatedLocalImage(context.Background(), imageName, ioutil.Discard)
+	}
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to fetch buildpack image %q", imageName)
+	}
+	if found, err := img.Found(); err != nil {
+		return "", "", err
+	} else if !found {
+		if f.Offline {
+			return "", "", fmt.Errorf("buildpack image %q is not in the daemon and cannot be pulled in offline mode", imageName)
+		}
+		return "", "", fmt.Errorf("buildpack image %q does not exist", imageName)
+	}
+
+	topLayer, err := img.TopLayer()
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get top layer of buildpack image %q", imageName)
+	}
+
+	layerDir, err := f.extractLayer(topLayer, func() (io.ReadCloser, error) { return img.GetLayer(topLayer) }, false)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to extract buildpack from image %q", imageName)
+	}
+	if err := f.recordCacheEntry(CacheEntry{URI: bp.URI}, layerDir); err != nil {
+		return "", "", err
+	}
+	dir, err := findBuildpackDir(layerDir, bp)
+	return dir, topLayer, err
+}
+
+// handleOCI reads an OCI image layout directory (oci:<path>, optionally followed by #<ref-name> to select a manifest)
+// and extracts the top layer of the selected image, returning the directory of the buildpack and the digest of the
+// layer.
+func (f *Fetcher) handleOCI(localSearchPath string, bp Buildpack, bpURL *url.URL) (string, string, error) {
+	layoutDir := bpURL.Opaque
+	if layoutDir == "" {
+		layoutDir = bpURL.Path
+	}
+	if !filepath.IsAbs(layoutDir) {
+		layoutDir = filepath.Join(localSearchPath, layoutDir)
+	}
+
+	var index ociIndex
+	if err := readJSONFile(filepath.Join(layoutDir, "index.json"), &index); err != nil {
+		return "", "", errors.Wrapf(err, "reading OCI layout %q", layoutDir)
+	}
+
+	manifestDesc, err := selectManifest(index, bpURL.Fragment)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "reading OCI layout %q", layoutDir)
+	}
+
+	var manifest ociManifest
+	if err := readJSONFile(blobPath(layoutDir, manifestDesc.Digest), &manifest); err != nil {
+		return "", "", errors.Wrapf(err, "reading manifest %s from OCI layout %q", manifestDesc.Digest, layoutDir)
+	}
+	if len(manifest.Layers) == 0 {
+		return "", "", fmt.Errorf("image in OCI layout %q has no layers", layoutDir)
+	}
+
+	top := manifest.Layers[len(manifest.Layers)-1]
+	layerDir, err := f.extractLayer(top.Digest, func() (io.ReadCloser, error) {
+		return os.Open(blobPath(layoutDir, top.Digest))
+	}, strings.HasSuffix(top.MediaType, "gzip"))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to extract buildpack from OCI layout %q", layoutDir)
+	}
+	// the layout is recorded by its absolute path, as a relative one depends on where it is referenced from
+	absLayoutDir, err := filepath.Abs(layoutDir)
+	if err != nil {
+		return "", "", err
+	}
+	uri := url.URL{Scheme: "oci", Opaque: absLayoutDir, Fragment: bpURL.Fragment}
+	if err := f.recordCacheEntry(CacheEntry{URI: uri.String()}, layerDir); err != nil {
+		return "", "", err
+	}
+	dir, err := findBuildpackDir(layerDir, bp)
+	return dir, top.Digest, err
+}
+
+func selectManifest(index ociIndex, refName string) (ociDescriptor, error) {
+	if refName == "" {
+		if len(index.Manifests) != 1 {
+			return ociDescriptor{}, fmt.Errorf("expected exactly one manifest but found %d -- select one with #<ref-name>", len(index.Manifests))
+		}
+		return index.Manifests[0], nil
+	}
+	for _, m := range index.Manifests {
+		if m.Annotations[ociRefNameAnnotation] == refName {
+			return m, nil
+		}
+	}
+	return ociDescriptor{}, fmt.Errorf("no manifest with ref name %q", refName)
+}
+
+// extractLayer extracts a layer into a cache directory named after its digest, so that a layer is only extracted
+// once no matter how many builders reference it.
+func (f *Fetcher) extractLayer(digest string, open func() (io.ReadCloser, error), gzipped bool) (string, error) {
+	layerDir := filepath.Join(f.CacheDir, "layers", strings.Replace(digest, ":", "-", -1))
+	if exists, err := fileExists(layerDir); err != nil {
+		return "", err
+	} else if exists {
+		f.Logger.Verbose("Using cached layer %s\n", digest)
+		return layerDir, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(layerDir), 0755); err != nil {
+		return "", err
+	}
+	tmpDir, err := ioutil.TempDir(filepath.Dir(layerDir), "extract")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	reader, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	if gzipped {
+		err = archive.ExtractTarGZ(reader, tmpDir)
+	} else {
+		err = archive.ExtractTar(reader, tmpDir)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmpDir, layerDir); err != nil {
+		return "", err
+	}
+	return layerDir, nil
+}
+
+// findBuildpackDir locates the directory below root containing the buildpack.toml for the buildpack with the ID and
+// version of bp, either of which matches any buildpack when it is not given. It fails unless exactly one buildpack
+// matches.
+func findBuildpackDir(root string, bp Buildpack) (string, error) {
+	var found, refs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != "buildpack.toml" {
+			return nil
+		}
+
+		var data struct {
+			BP struct {
+				ID      string `toml:"id"`
+				Version string `toml:"version"`
+			} `toml:"buildpack"`
+		}
+		if _, err := toml.DecodeFile(path, &data); err != nil {
+			return errors.Wrapf(err, "reading %s", path)
+		}
+		if (bp.ID == "" || data.BP.ID == bp.ID) && (bp.Version == "" || data.BP.Version == bp.Version) {
+			found = append(found, filepath.Dir(path))
+			refs = append(refs, data.BP.ID+"@"+data.BP.Version)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ref := bp.ID
+	if bp.Version != "" {
+		ref += "@" + bp.Version
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("could not find buildpack %q in %q", ref, bp.URI)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("found several buildpacks matching %q in %q (%s), set the id and version of the buildpack to select one", ref, bp.URI, strings.Join(refs, ", "))
+	}
+}
+
+func blobPath(layoutDir, digest string) string {
+	return filepath.Join(layoutDir, "blobs", strings.Replace(digest, ":", string(filepath.Separator), 1))
+}
+
+func readJSONFile(path string, v interface{}) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, v)
+}
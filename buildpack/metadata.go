@@ -6,8 +6,14 @@ type Buildpack struct {
 	ID      string `toml:"id"`
 	URI     string `toml:"uri"`
 	Latest  bool   `toml:"latest"`
+	SHA256  string `toml:"sha256"`
 	Dir     string
 	Version string
+	Commit  string // the git commit the buildpack was fetched from, for git+ URIs
+
+	// SourceDigest identifies the archive, image layer or git commit the buildpack was fetched from, and is empty for
+	// buildpack directories.
+	SourceDigest string
 }
 
 func (b *Buildpack) EscapedID() string {
@@ -16,13 +16,13 @@ import (
 )
 
 var (
-	Version           = "0.0.0"
-	timestamps, quiet bool
-	logger            logging.Logger
-	cfg               config.Config
-	client            pack.Client
-	imageFetcher      pack.ImageFetcher
-	buildpackFetcher  buildpack.Fetcher
+	Version                    = "0.0.0"
+	timestamps, quiet, offline bool
+	logger                     logging.Logger
+	cfg                        config.Config
+	client                     pack.Client
+	imageFetcher               pack.ImageFetcher
+	buildpackFetcher           buildpack.Fetcher
 )
 
 func main() {
@@ -33,23 +33,33 @@ func main() {
 			logger = *logging.NewLogger(os.Stdout, os.Stderr, !quiet, timestamps)
 			cfg = initConfig(logger)
 			imageFetcher = initImageFetcher(logger)
-			buildpackFetcher = initBuildpackFetcher(logger)
+			buildpackFetcher = initBuildpackFetcher(logger, &imageFetcher)
 			client = *pack.NewClient(&cfg, &imageFetcher)
 		},
 	}
 	rootCmd.PersistentFlags().BoolVar(&color.NoColor, "no-color", false, "Disable color output")
 	rootCmd.PersistentFlags().BoolVar(&timestamps, "timestamps", false, "Enable timestamps in output")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Show less output")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Only use buildpacks from the download cache, without network access")
 	commands.AddHelpFlag(rootCmd, "pack")
 
-	rootCmd.AddCommand(commands.Build(&logger, &imageFetcher))
-	rootCmd.AddCommand(commands.Run(&logger, &imageFetcher))
+	rootCmd.AddCommand(commands.Build(&logger, &imageFetcher, &buildpackFetcher))
+	rootCmd.AddCommand(commands.Run(&logger, &imageFetcher, &buildpackFetcher))
 	rootCmd.AddCommand(commands.Rebase(&logger, &imageFetcher))
+	rootCmd.AddCommand(commands.Exec(&logger, &imageFetcher))
+	rootCmd.AddCommand(commands.Shell(&logger, &imageFetcher))
 
-	rootCmd.AddCommand(commands.CreateBuilder(&logger, &imageFetcher, &buildpackFetcher))
+	rootCmd.AddCommand(commands.CreateBuilder(&logger, &imageFetcher, &buildpackFetcher, Version))
+	rootCmd.AddCommand(commands.Builder(&logger, &imageFetcher, &buildpackFetcher, Version))
+	rootCmd.AddCommand(commands.CreateStack(&logger, &imageFetcher))
+	rootCmd.AddCommand(commands.PackageBuildpack(&logger, &buildpackFetcher))
+	rootCmd.AddCommand(commands.Buildpack(&logger, &buildpackFetcher))
 	rootCmd.AddCommand(commands.SetRunImagesMirrors(&logger))
 	rootCmd.AddCommand(commands.InspectBuilder(&logger, &cfg, &client))
-	rootCmd.AddCommand(commands.SetDefaultBuilder(&logger))
+	rootCmd.AddCommand(commands.InspectImage(&logger, &client))
+	rootCmd.AddCommand(commands.Diff(&logger, &client))
+	rootCmd.AddCommand(commands.SBOM(&logger, &client))
+	rootCmd.AddCommand(commands.SetDefaultBuilder(&logger, &client))
 
 	rootCmd.AddCommand(commands.Version(&logger, Version))
 
@@ -86,8 +96,10 @@ func initImageFetcher(logger logging.Logger) pack.ImageFetcher {
 	}
 }
 
-func initBuildpackFetcher(logger logging.Logger) buildpack.Fetcher {
-	return *buildpack.NewFetcher(&logger, cfg.Path())
+func initBuildpackFetcher(logger logging.Logger, imageFetcher buildpack.ImageFetcher) buildpack.Fetcher {
+	fetcher := buildpack.NewFetcher(&logger, imageFetcher, cfg.Path())
+	fetcher.Offline = offline
+	return *fetcher
 }
 
 func exitError(logger logging.Logger, err error) {
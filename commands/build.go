@@ -35,7 +35,7 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-func Build(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
+func Build(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) *cobra.Command {
 	var buildFlags pack.BuildFlags
 	ctx := createCancellableContext()
 
@@ -59,6 +59,7 @@ func Build(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			bf.BuildpackFetcher = bpFetcher
 
 			if bf.Config.DefaultBuilder == "" && buildFlags.Builder == "" {
 				suggestSettingBuilder(logger)
@@ -78,6 +79,7 @@ func Build(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
 	}
 	buildCommandFlags(cmd, &buildFlags)
 	cmd.Flags().BoolVar(&buildFlags.Publish, "publish", false, "Publish to registry")
+	cmd.Flags().StringVar(&buildFlags.SBOMDir, "sbom-dir", "", "Directory to write the built image's software bill of materials to, in SPDX and CycloneDX JSON formats")
 	AddHelpFlag(cmd, "build")
 	return cmd
 }
@@ -113,5 +115,6 @@ func buildCommandFlags(cmd *cobra.Command, buildFlags *pack.BuildFlags) {
 	cmd.Flags().StringVar(&buildFlags.EnvFile, "env-file", "", "Build-time environment variables file\nOne variable per line, of the form 'VAR=VALUE' or 'VAR'\nWhen using latter value-less form, value will be taken from current\n  environment at the time this command is executed")
 	cmd.Flags().BoolVar(&buildFlags.NoPull, "no-pull", false, "Skip pulling builder and run images before use")
 	cmd.Flags().BoolVar(&buildFlags.ClearCache, "clear-cache", false, "Clear image's associated cache before building")
+	cmd.Flags().BoolVar(&buildFlags.Force, "force", false, "Use the run image even if its stack does not match the builder's stack")
 	cmd.Flags().StringSliceVar(&buildFlags.Buildpacks, "buildpack", nil, "Buildpack ID or path to a buildpack directory"+multiValueHelp("buildpack"))
 }
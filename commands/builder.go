@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/docker"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func Builder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher, packVersion string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "builder",
+		Short: "Interact with existing builders",
+	}
+	cmd.AddCommand(UpdateBuilder(logger, fetcher, bpFetcher, packVersion))
+	AddHelpFlag(cmd, "builder")
+	return cmd
+}
+
+func UpdateBuilder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher, packVersion string) *cobra.Command {
+	var flags pack.UpdateBuilderFlags
+	ctx := createCancellableContext()
+	cmd := &cobra.Command{
+		Use:   "update <builder-image-name> --tag <image-name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Add or remove buildpacks of an existing builder, or change its detection order",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.BuilderName = args[0]
+			if runtime.GOOS == "windows" {
+				return fmt.Errorf("%s is not implemented on Windows", style.Symbol("builder update"))
+			}
+
+			cfg, err := config.NewDefault()
+			if err != nil {
+				return err
+			}
+			dockerClient, err := docker.New()
+			if err != nil {
+				return err
+			}
+			builderFactory := pack.BuilderFactory{
+				PackVersion:      packVersion,
+				Logger:           logger,
+				Config:           cfg,
+				Fetcher:          fetcher,
+				BuildpackFetcher: bpFetcher,
+				Docker:           dockerClient,
+			}
+			updateConfig, err := builderFactory.UpdateBuilderConfigFromFlags(ctx, flags)
+			if err != nil {
+				return err
+			}
+			if err := builderFactory.Update(updateConfig); err != nil {
+				return err
+			}
+			imageName := updateConfig.Repo.Name()
+			logger.Info("Successfully updated builder %s as %s", style.Symbol(flags.BuilderName), style.Symbol(imageName))
+			logger.Tip("Run %s to use this builder", style.Symbol(fmt.Sprintf("pack build <image-name> --builder %s", imageName)))
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&flags.Tag, "tag", "t", "", "Name of the updated builder image (required)")
+	cmd.MarkFlagRequired("tag")
+	cmd.Flags().StringSliceVar(&flags.AddBuildpacks, "add-buildpack", nil, "URI of a buildpack to add"+multiValueHelp("buildpack"))
+	cmd.Flags().StringSliceVar(&flags.RemoveBuildpacks, "remove-buildpack", nil, "Buildpack to remove, as <id>@<version>"+multiValueHelp("buildpack"))
+	cmd.Flags().StringVar(&flags.OrderPath, "order", "", "Path to an order.toml replacing the detection order of the builder")
+	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling the builder before use")
+	cmd.Flags().BoolVar(&flags.Publish, "publish", false, "Publish to registry")
+	cmd.Flags().BoolVar(&flags.UseCurrentTime, "use-current-time", false, "Record the current time as the creation time of the builder, instead of a fixed time")
+	AddHelpFlag(cmd, "builder update")
+	return cmd
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+//go:generate mockgen -package mocks -destination mocks/buildpack_cache.go github.com/buildpack/pack/commands BuildpackCache
+type BuildpackCache interface {
+	CacheEntries() ([]buildpack.CacheEntry, error)
+	PruneCache(unusedSince time.Time) ([]buildpack.CacheEntry, error)
+}
+
+func Buildpack(logger *logging.Logger, cache BuildpackCache) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "buildpack",
+		Short: "Interact with buildpacks",
+	}
+	cmd.AddCommand(BuildpackCacheCommand(logger, cache))
+	AddHelpFlag(cmd, "buildpack")
+	return cmd
+}
+
+func BuildpackCacheCommand(logger *logging.Logger, cache BuildpackCache) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the download cache of buildpacks",
+	}
+	cmd.AddCommand(listBuildpackCache(logger, cache))
+	cmd.AddCommand(pruneBuildpackCache(logger, cache))
+	AddHelpFlag(cmd, "buildpack cache")
+	return cmd
+}
+
+func listBuildpackCache(logger *logging.Logger, cache BuildpackCache) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Args:  cobra.NoArgs,
+		Short: "List the buildpacks in the download cache",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			entries, err := cache.CacheEntries()
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				logger.Info("The download cache is empty")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(logger.RawWriter(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "URI\tETAG\tSIZE\tLAST USED")
+			for _, entry := range entries {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.URI, valueOrDash(entry.ETag), humanSize(entry.Size), entry.LastUsed.Local().Format("2006-01-02 15:04:05"))
+			}
+			return tw.Flush()
+		}),
+	}
+	AddHelpFlag(cmd, "buildpack cache list")
+	return cmd
+}
+
+func pruneBuildpackCache(logger *logging.Logger, cache BuildpackCache) *cobra.Command {
+	var (
+		all       bool
+		unusedFor time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Args:  cobra.NoArgs,
+		Short: "Remove unused buildpacks from the download cache",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			if all && unusedFor != 0 {
+				return fmt.Errorf("%s and %s cannot be used together", style.Symbol("--all"), style.Symbol("--unused-for"))
+			}
+
+			// without either flag, only files no longer referred to by any entry are removed
+			unusedSince := time.Time{}
+			if all {
+				unusedSince = time.Now().Add(time.Second)
+			} else if unusedFor != 0 {
+				unusedSince = time.Now().Add(-unusedFor)
+			}
+
+			removed, err := cache.PruneCache(unusedSince)
+			if err != nil {
+				return err
+			}
+			var size int64
+			for _, entry := range removed {
+				logger.Verbose("Removed %s", entry.URI)
+				size += entry.Size
+			}
+			logger.Info("Removed %d entries from the download cache, freeing %s", len(removed), humanSize(size))
+			return nil
+		}),
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Remove all entries")
+	cmd.Flags().DurationVar(&unusedFor, "unused-for", 0, "Remove entries that have not been used for this long (e.g. 720h)")
+	AddHelpFlag(cmd, "buildpack cache prune")
+	return cmd
+}
+
+// humanSize formats a number of bytes with decimal units, like docker does.
+func humanSize(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d%s", size, units[0])
+	}
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
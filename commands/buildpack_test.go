@@ -0,0 +1,96 @@
+package commands_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/commands"
+	cmdmocks "github.com/buildpack/pack/commands/mocks"
+	"github.com/buildpack/pack/logging"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestBuildpackCommand(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "Commands", testBuildpackCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBuildpackCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		command        *cobra.Command
+		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockCache      *cmdmocks.MockBuildpackCache
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockCache = cmdmocks.NewMockBuildpackCache(mockController)
+		command = commands.Buildpack(logging.NewLogger(&outBuf, &outBuf, false, false), mockCache)
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#BuildpackCacheList", func() {
+		it("shows the URI, ETag, size and last use of each entry", func() {
+			lastUsed := time.Date(2019, time.March, 1, 12, 30, 0, 0, time.Local)
+			mockCache.EXPECT().CacheEntries().Return([]buildpack.CacheEntry{
+				{URI: "https://example.com/some-buildpack.tgz", ETag: `"some-etag"`, Size: 1234567, LastUsed: lastUsed},
+				{URI: "git+https://example.com/other-buildpack", Size: 512, LastUsed: lastUsed},
+			}, nil)
+
+			command.SetArgs([]string{"cache", "list"})
+			h.AssertNil(t, command.Execute())
+			h.AssertContains(t, outBuf.String(), "URI                                       ETAG          SIZE    LAST USED")
+			h.AssertContains(t, outBuf.String(), `https://example.com/some-buildpack.tgz    "some-etag"   1.2MB   2019-03-01 12:30:00`)
+			h.AssertContains(t, outBuf.String(), "git+https://example.com/other-buildpack   -             512B    2019-03-01 12:30:00")
+		})
+
+		it("says when the cache is empty", func() {
+			mockCache.EXPECT().CacheEntries().Return(nil, nil)
+
+			command.SetArgs([]string{"cache", "list"})
+			h.AssertNil(t, command.Execute())
+			h.AssertContains(t, outBuf.String(), "The download cache is empty")
+		})
+	})
+
+	when("#BuildpackCachePrune", func() {
+		it("only removes unreferenced files by default", func() {
+			mockCache.EXPECT().PruneCache(time.Time{}).Return(nil, nil)
+
+			command.SetArgs([]string{"cache", "prune"})
+			h.AssertNil(t, command.Execute())
+			h.AssertContains(t, outBuf.String(), "Removed 0 entries from the download cache, freeing 0B")
+		})
+
+		it("removes entries that have not been used for the given duration", func() {
+			mockCache.EXPECT().PruneCache(gomock.Any()).DoAndReturn(func(unusedSince time.Time) ([]buildpack.CacheEntry, error) {
+				if since := time.Since(unusedSince); since < 48*time.Hour || since > 49*time.Hour {
+					t.Fatalf("expected entries unused for 48h to be removed, got entries unused since %s", unusedSince)
+				}
+				return []buildpack.CacheEntry{{URI: "https://example.com/some-buildpack.tgz", Size: 2500}}, nil
+			})
+
+			command.SetArgs([]string{"cache", "prune", "--unused-for", "48h"})
+			h.AssertNil(t, command.Execute())
+			h.AssertContains(t, outBuf.String(), "Removed 1 entries from the download cache, freeing 2.5kB")
+		})
+
+		it("fails when both --all and --unused-for are given", func() {
+			command.SetArgs([]string{"cache", "prune", "--all", "--unused-for", "48h"})
+			h.AssertNotNil(t, command.Execute())
+			h.AssertContains(t, outBuf.String(), "'--all' and '--unused-for' cannot be used together")
+		})
+	})
+}
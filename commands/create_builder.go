@@ -8,11 +8,12 @@ import (
 
 	"github.com/buildpack/pack"
 	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/docker"
 	"github.com/buildpack/pack/logging"
 	"github.com/buildpack/pack/style"
 )
 
-func CreateBuilder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) *cobra.Command {
+func CreateBuilder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher, packVersion string) *cobra.Command {
 	var flags pack.CreateBuilderFlags
 	ctx := createCancellableContext()
 	cmd := &cobra.Command{
@@ -29,11 +30,17 @@ func CreateBuilder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.
 			if err != nil {
 				return err
 			}
+			dockerClient, err := docker.New()
+			if err != nil {
+				return err
+			}
 			builderFactory := pack.BuilderFactory{
+				PackVersion:      packVersion,
 				Logger:           logger,
 				Config:           cfg,
 				Fetcher:          fetcher,
 				BuildpackFetcher: bpFetcher,
+				Docker:           dockerClient,
 			}
 			builderConfig, err := builderFactory.BuilderConfigFromFlags(ctx, flags)
 			if err != nil {
@@ -42,6 +49,10 @@ func CreateBuilder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.
 			if err := builderFactory.Create(builderConfig); err != nil {
 				return err
 			}
+			if flags.DryRun {
+				logger.Info("Dry run complete, builder image %s was not created", style.Symbol(flags.RepoName))
+				return nil
+			}
 			imageName := builderConfig.Repo.Name()
 			logger.Info("Successfully created builder image %s", style.Symbol(imageName))
 			logger.Tip("Run %s to use this builder", style.Symbol(fmt.Sprintf("pack build <image-name> --builder %s", imageName)))
@@ -52,6 +63,8 @@ func CreateBuilder(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.
 	cmd.Flags().StringVarP(&flags.BuilderTomlPath, "builder-config", "b", "", "Path to builder TOML file (required)")
 	cmd.MarkFlagRequired("builder-config")
 	cmd.Flags().BoolVar(&flags.Publish, "publish", false, "Publish to registry")
+	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "Validate the builder and print its detection order without creating an image")
+	cmd.Flags().BoolVar(&flags.UseCurrentTime, "use-current-time", false, "Record the current time as the creation time of the builder, instead of a fixed time")
 	AddHelpFlag(cmd, "create-builder")
 	return cmd
 }
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/docker"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func CreateStack(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
+	var flags pack.CreateStackFlags
+	ctx := createCancellableContext()
+	cmd := &cobra.Command{
+		Use:   "create-stack --base <base-image-name> --id <stack-id>",
+		Args:  cobra.NoArgs,
+		Short: "Create the build image and run image of a stack from a base image",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			dockerClient, err := docker.New()
+			if err != nil {
+				return err
+			}
+			stackFactory := pack.StackFactory{
+				Logger:  logger,
+				Docker:  dockerClient,
+				Fetcher: fetcher,
+			}
+			stackConfig, err := stackFactory.CreateStackConfigFromFlags(flags)
+			if err != nil {
+				return err
+			}
+			if err := stackFactory.Create(ctx, stackConfig); err != nil {
+				return err
+			}
+			logger.Info("Successfully created stack %s with build image %s and run image %s",
+				style.Symbol(stackConfig.StackID), style.Symbol(stackConfig.BuildImage), style.Symbol(stackConfig.RunImage))
+			logger.Tip("Use them as %s and %s in the %s table of a builder.toml",
+				style.Symbol(fmt.Sprintf("build-image = %q", stackConfig.BuildImage)), style.Symbol(fmt.Sprintf("run-image = %q", stackConfig.RunImage)), style.Symbol("[stack]"))
+			return nil
+		}),
+	}
+	cmd.Flags().StringVar(&flags.BaseImage, "base", "", "Base image of the build image and run image (required)")
+	cmd.MarkFlagRequired("base")
+	cmd.Flags().StringVar(&flags.StackID, "id", "", "ID of the stack (required)")
+	cmd.MarkFlagRequired("id")
+	cmd.Flags().StringVar(&flags.BuildImage, "build-image", "", "Name of the build image (defaults to <stack-id>-build)")
+	cmd.Flags().StringVar(&flags.RunImage, "run-image", "", "Name of the run image (defaults to <stack-id>-run)")
+	cmd.Flags().IntVar(&flags.UserID, "user-id", 1000, "ID of the user buildpacks and apps run as")
+	cmd.Flags().IntVar(&flags.GroupID, "group-id", 1000, "ID of the group buildpacks and apps run as")
+	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling the base image before use")
+	AddHelpFlag(cmd, "create-stack")
+	return cmd
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+//go:generate mockgen -package mocks -destination mocks/image_differ.go github.com/buildpack/pack/commands ImageDiffer
+type ImageDiffer interface {
+	DiffImages(string, string, bool) (*pack.ImageDiff, error)
+}
+
+func Diff(logger *logging.Logger, differ ImageDiffer) *cobra.Command {
+	var (
+		remote bool
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "diff <image-a> <image-b>",
+		Short: "Show differences between the buildpack metadata of two app images",
+		Args:  cobra.ExactArgs(2),
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputFormat(output); err != nil {
+				return err
+			}
+
+			diff, err := differ.DiffImages(args[0], args[1], !remote)
+			if err != nil {
+				return err
+			}
+
+			if output != "" {
+				return writeOutput(logger.RawWriter(), output, diff)
+			}
+
+			logger.Info("Comparing %s and %s\n", style.Symbol(args[0]), style.Symbol(args[1]))
+			if diff.Empty() {
+				logger.Info("No differences found")
+				return nil
+			}
+			logImageDiff(logger, diff)
+			return nil
+		}),
+	}
+	cmd.Flags().BoolVar(&remote, "remote", false, "Compare images in the registry instead of the local daemon")
+	cmd.Flags().StringVarP(&output, "output", "o", "", outputFlagHelp)
+	AddHelpFlag(cmd, "diff")
+	return cmd
+}
+
+func logImageDiff(logger *logging.Logger, diff *pack.ImageDiff) {
+	logValueChange(logger, "Run image", diff.RunImage)
+	logValueChange(logger, "Run image digest", diff.RunImageDigest)
+	logValueChange(logger, "Run image top layer", diff.RunImageTopLayer)
+	logValueChange(logger, "Launcher layer", diff.Launcher)
+	logValueChange(logger, "Config layer", diff.Config)
+	logValueChange(logger, "App layer", diff.App)
+
+	if len(diff.Buildpacks) == 0 {
+		logger.Info("Buildpacks: unchanged")
+		return
+	}
+
+	logger.Info("\nBuildpacks:")
+	for _, bp := range diff.Buildpacks {
+		switch bp.Status {
+		case pack.DiffAdded:
+			logger.Info("  %s: added (%s)", bp.ID, bp.NewVersion)
+		case pack.DiffRemoved:
+			logger.Info("  %s: removed (%s)", bp.ID, bp.OldVersion)
+		default:
+			if bp.OldVersion != bp.NewVersion {
+				logger.Info("  %s: %s -> %s", bp.ID, bp.OldVersion, bp.NewVersion)
+			} else {
+				logger.Info("  %s: %s", bp.ID, bp.OldVersion)
+			}
+		}
+
+		for _, layer := range bp.Layers {
+			switch layer.Status {
+			case pack.DiffAdded:
+				logger.Info("    %s: added (%s)", layer.Name, layer.NewSHA)
+			case pack.DiffRemoved:
+				logger.Info("    %s: removed (%s)", layer.Name, layer.OldSHA)
+			default:
+				logger.Info("    %s: %s -> %s", layer.Name, layer.OldSHA, layer.NewSHA)
+			}
+		}
+	}
+}
+
+func logValueChange(logger *logging.Logger, name string, change *pack.ValueChange) {
+	if change == nil {
+		logger.Info("%s: unchanged", name)
+		return
+	}
+	logger.Info("%s: %s -> %s", name, change.Old, change.New)
+}
@@ -0,0 +1,96 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/commands"
+	cmdmocks "github.com/buildpack/pack/commands/mocks"
+	"github.com/buildpack/pack/logging"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestDiffCommand(t *testing.T) {
+	spec.Run(t, "Commands", testDiffCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testDiffCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		command        *cobra.Command
+		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockDiffer     *cmdmocks.MockImageDiffer
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDiffer = cmdmocks.NewMockImageDiffer(mockController)
+		command = commands.Diff(logging.NewLogger(&outBuf, &outBuf, false, false), mockDiffer)
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#Diff", func() {
+		it("displays the differences", func() {
+			mockDiffer.EXPECT().DiffImages("some/app:a", "some/app:b", true).Return(&pack.ImageDiff{
+				RunImageTopLayer: &pack.ValueChange{Old: "sha256:top-a", New: "sha256:top-b"},
+				App:              &pack.ValueChange{Old: "sha256:app-a", New: "sha256:app-b"},
+				Buildpacks: []pack.BuildpackDiff{
+					{
+						ID:         "bp.changed",
+						Status:     pack.DiffChanged,
+						OldVersion: "1.0.0",
+						NewVersion: "1.1.0",
+						Layers: []pack.LayerDiff{
+							{Name: "changed", Status: pack.DiffChanged, OldSHA: "sha256:a", NewSHA: "sha256:b"},
+							{Name: "added", Status: pack.DiffAdded, NewSHA: "sha256:c"},
+						},
+					},
+					{ID: "bp.removed", Status: pack.DiffRemoved, OldVersion: "1.0.0"},
+				},
+			}, nil)
+
+			command.SetArgs([]string{"some/app:a", "some/app:b"})
+			h.AssertNil(t, command.Execute())
+			h.AssertEq(t, outBuf.String(), `Comparing 'some/app:a' and 'some/app:b'
+
+Run image: unchanged
+Run image digest: unchanged
+Run image top layer: sha256:top-a -> sha256:top-b
+Launcher layer: unchanged
+Config layer: unchanged
+App layer: sha256:app-a -> sha256:app-b
+
+Buildpacks:
+  bp.changed: 1.0.0 -> 1.1.0
+    changed: sha256:a -> sha256:b
+    added: added (sha256:c)
+  bp.removed: removed (1.0.0)
+`)
+		})
+
+		it("reports when there are no differences", func() {
+			mockDiffer.EXPECT().DiffImages("some/app:a", "some/app:b", false).Return(&pack.ImageDiff{}, nil)
+
+			command.SetArgs([]string{"some/app:a", "some/app:b", "--remote"})
+			h.AssertNil(t, command.Execute())
+			h.AssertContains(t, outBuf.String(), "No differences found")
+		})
+
+		it("returns an error when the images cannot be compared", func() {
+			mockDiffer.EXPECT().DiffImages("some/app:a", "some/app:b", true).Return(nil, errors.New("some error"))
+
+			command.SetArgs([]string{"some/app:a", "some/app:b"})
+			h.AssertError(t, command.Execute(), "some error")
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/docker"
+	"github.com/buildpack/pack/logging"
+)
+
+func Exec(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
+	var flags pack.ExecFlags
+	ctx := createCancellableContext()
+
+	cmd := &cobra.Command{
+		Use:   "exec <image-name> -- <command>...",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Run a command in a container from an app image using the launch environment",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.RepoName = args[0]
+			flags.Command = args[1:]
+			return execInAppImage(ctx, logger, fetcher, flags)
+		}),
+	}
+	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling app image before use")
+	AddHelpFlag(cmd, "exec")
+	return cmd
+}
+
+func Shell(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
+	var (
+		flags pack.ExecFlags
+		shell string
+	)
+	ctx := createCancellableContext()
+
+	cmd := &cobra.Command{
+		Use:   "shell <image-name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Start a shell in a container from an app image using the launch environment",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.RepoName = args[0]
+			flags.Command = []string{shell}
+			return execInAppImage(ctx, logger, fetcher, flags)
+		}),
+	}
+	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling app image before use")
+	cmd.Flags().StringVar(&shell, "shell", "bash", "Shell to start in the container")
+	AddHelpFlag(cmd, "shell")
+	return cmd
+}
+
+func execInAppImage(ctx context.Context, logger *logging.Logger, fetcher pack.Fetcher, flags pack.ExecFlags) error {
+	dockerClient, err := docker.New()
+	if err != nil {
+		return err
+	}
+	factory := pack.ExecFactory{
+		Cli:     dockerClient,
+		Logger:  logger,
+		Fetcher: fetcher,
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+	execConfig, err := factory.ExecConfigFromFlags(ctx, flags)
+	if err != nil {
+		return err
+	}
+	return execConfig.Run(ctx)
+}
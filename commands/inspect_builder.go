@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"text/tabwriter"
+	"time"
 )
 
 //go:generate mockgen -package mocks -destination mocks/inspect_builder.go github.com/buildpack/pack/commands BuilderInspector
@@ -17,7 +18,17 @@ type BuilderInspector interface {
 	InspectBuilder(string, bool) (*pack.BuilderInfo, error)
 }
 
+type builderOutput struct {
+	BuilderName string            `json:"builder_name" yaml:"builder_name" toml:"builder_name"`
+	Remote      *pack.BuilderInfo `json:"remote" yaml:"remote" toml:"remote"`
+	Local       *pack.BuilderInfo `json:"local" yaml:"local" toml:"local"`
+}
+
 func InspectBuilder(logger *logging.Logger, cfg *config.Config, inspector BuilderInspector) *cobra.Command {
+	var (
+		output      string
+		buildpackID string
+	)
 	cmd := &cobra.Command{
 		Use:   "inspect-builder <builder-image-name>",
 		Short: "Show information about a builder",
@@ -28,17 +39,39 @@ func InspectBuilder(logger *logging.Logger, cfg *config.Config, inspector Builde
 				return MakeSoftError()
 			}
 
+			if err := validateOutputFormat(output); err != nil {
+				return err
+			}
+
 			imageName := cfg.DefaultBuilder
 			if len(args) >= 1 {
 				imageName = args[0]
 			}
 
+			if output != "" {
+				return writeOutput(logger.RawWriter(), output, builderOutput{
+					BuilderName: imageName,
+					Remote:      filterBuildpacks(inspectBuilderInfo(logger, inspector, imageName, false), buildpackID),
+					Local:       filterBuildpacks(inspectBuilderInfo(logger, inspector, imageName, true), buildpackID),
+				})
+			}
+
 			if imageName == cfg.DefaultBuilder {
 				logger.Info("Inspecting default builder: %s\n", style.Symbol(imageName))
 			} else {
 				logger.Info("Inspecting builder: %s\n", style.Symbol(imageName))
 			}
 
+			if buildpackID != "" {
+				logger.Info("Remote\n------\n")
+				inspectBuildpackOutput(logger, inspector, imageName, buildpackID, false)
+
+				logger.Info("\nLocal\n-----\n")
+				inspectBuildpackOutput(logger, inspector, imageName, buildpackID, true)
+
+				return nil
+			}
+
 			logger.Info("Remote\n------\n")
 			inspectBuilderOutput(logger, inspector, imageName, false)
 
@@ -48,10 +81,21 @@ func InspectBuilder(logger *logging.Logger, cfg *config.Config, inspector Builde
 			return nil
 		}),
 	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", outputFlagHelp)
+	cmd.Flags().StringVar(&buildpackID, "buildpack", "", "Only show details of the buildpack with this ID")
 	AddHelpFlag(cmd, "inspect-builder")
 	return cmd
 }
 
+func inspectBuilderInfo(logger *logging.Logger, inspector BuilderInspector, imageName string, local bool) *pack.BuilderInfo {
+	info, err := inspector.InspectBuilder(imageName, local)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to inspect image %s", style.Symbol(imageName)).Error())
+		return nil
+	}
+	return info
+}
+
 func inspectBuilderOutput(logger *logging.Logger, inspector BuilderInspector, imageName string, local bool) {
 	info, err := inspector.InspectBuilder(imageName, local)
 	if err != nil {
@@ -64,8 +108,25 @@ func inspectBuilderOutput(logger *logging.Logger, inspector BuilderInspector, im
 		return
 	}
 
+	if info.Description != "" {
+		logger.Info("Description: %s\n", info.Description)
+	}
+
 	logger.Info("Stack: %s\n", info.Stack)
 
+	if info.LifecycleVersion != "" {
+		logger.Info("Lifecycle: %s\n", info.LifecycleVersion)
+	}
+
+	if c := info.CreatedBy; c != nil {
+		logger.Info("Created: %s by pack %s", c.Created.Format(time.RFC3339), valueOrDash(c.PackVersion))
+		if c.BuildImageDigest != "" {
+			logger.Info("Build Image: %s@%s\n", c.BuildImage, c.BuildImageDigest)
+		} else {
+			logger.Info("Build Image: %s\n", c.BuildImage)
+		}
+	}
+
 	logger.Info("Run Images:")
 	for _, r := range info.LocalRunImageMirrors {
 		logger.Info("  %s (user-configured)", r)
@@ -93,12 +154,12 @@ func inspectBuilderOutput(logger *logging.Logger, inspector BuilderInspector, im
 func logBuildpacksInfo(logger *logging.Logger, info *pack.BuilderInfo) {
 	buf := &bytes.Buffer{}
 	tabWriter := new(tabwriter.Writer).Init(buf, 0, 0, 8, ' ', 0)
-	if _, err := fmt.Fprint(tabWriter, "\n  ID\tVERSION\tLATEST\t"); err != nil {
+	if _, err := fmt.Fprint(tabWriter, "\n  ID\tVERSION\tLATEST\tNAME\t"); err != nil {
 		logger.Error(err.Error())
 	}
 
 	for _, bp := range info.Buildpacks {
-		if _, err := fmt.Fprint(tabWriter, fmt.Sprintf("\n  %s\t%s\t%t\t", bp.ID, bp.Version, bp.Latest)); err != nil {
+		if _, err := fmt.Fprint(tabWriter, fmt.Sprintf("\n  %s\t%s\t%t\t%s\t", bp.ID, bp.Version, bp.Latest, valueOrDash(bp.Name))); err != nil {
 			logger.Error(err.Error())
 		}
 	}
@@ -114,8 +175,72 @@ func logDetectionOrderInfo(logger *logging.Logger, info *pack.BuilderInfo) {
 	logger.Info("\nDetection Order:")
 	for i, group := range info.Groups {
 		logger.Info(fmt.Sprintf("  Group #%d:", i+1))
-		for _, bp := range group {
-			logger.Info(fmt.Sprintf("    %s@%s", bp.ID, bp.Version))
+		for _, bp := range group.Buildpacks {
+			if bp.Optional {
+				logger.Info(fmt.Sprintf("    %s@%s (optional)", bp.ID, bp.Version))
+			} else {
+				logger.Info(fmt.Sprintf("    %s@%s", bp.ID, bp.Version))
+			}
+		}
+	}
+}
+
+// filterBuildpacks narrows the buildpacks in info down to those with the given ID. Detection order is dropped, as
+// it is not meaningful for a single buildpack.
+func filterBuildpacks(info *pack.BuilderInfo, buildpackID string) *pack.BuilderInfo {
+	if info == nil || buildpackID == "" {
+		return info
+	}
+
+	filtered := *info
+	filtered.Buildpacks = nil
+	filtered.Groups = nil
+	for _, bp := range info.Buildpacks {
+		if bp.ID == buildpackID {
+			filtered.Buildpacks = append(filtered.Buildpacks, bp)
+		}
+	}
+	return &filtered
+}
+
+func inspectBuildpackOutput(logger *logging.Logger, inspector BuilderInspector, imageName, buildpackID string, local bool) {
+	info, err := inspector.InspectBuilder(imageName, local)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to inspect image %s", style.Symbol(imageName)).Error())
+		return
+	}
+
+	if info == nil {
+		logger.Info("Not present")
+		return
+	}
+
+	buildpacks := filterBuildpacks(info, buildpackID).Buildpacks
+	if len(buildpacks) == 0 {
+		logger.Info("Buildpack %s not found in %s", style.Symbol(buildpackID), style.Symbol(imageName))
+		return
+	}
+
+	for i, bp := range buildpacks {
+		if i > 0 {
+			logger.Info("")
+		}
+		logger.Info("ID: %s", bp.ID)
+		logger.Info("Version: %s", bp.Version)
+		logger.Info("Latest: %t", bp.Latest)
+		logger.Info("Name: %s", valueOrDash(bp.Name))
+		logger.Info("Description: %s", valueOrDash(bp.Description))
+		logger.Info("Homepage: %s", valueOrDash(bp.Homepage))
+		if bp.Commit != "" {
+			logger.Info("Commit: %s", bp.Commit)
+		}
+		if len(bp.Stacks) == 0 {
+			logger.Info("Stacks: -")
+		} else {
+			logger.Info("Stacks:")
+			for _, s := range bp.Stacks {
+				logger.Info("  %s", s)
+			}
 		}
 	}
 }
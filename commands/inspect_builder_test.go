@@ -6,6 +6,7 @@ import (
 	"github.com/buildpack/pack"
 	"github.com/buildpack/pack/config"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sclevine/spec"
@@ -106,10 +107,54 @@ ERROR: failed to inspect image 'some/image': some local error
 			})
 		})
 
+		when("the builder has a description and records how it was created", func() {
+			it.Before(func() {
+				info := &pack.BuilderInfo{
+					Description: "Some builder for some apps",
+					Stack:       "test.stack.id",
+					RunImage:    "some/run-image",
+					CreatedBy: &pack.CreatorInfo{
+						PackVersion:      "0.2.0",
+						Created:          time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC),
+						BuildImage:       "some/build-image",
+						BuildImageDigest: "sha256:abc",
+					},
+				}
+				mockInspector.EXPECT().InspectBuilder("some/image", false).Return(info, nil)
+				mockInspector.EXPECT().InspectBuilder("some/image", true).Return(info, nil)
+				command.SetArgs([]string{"some/image"})
+			})
+
+			it("shows them before the stack", func() {
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), `
+Remote
+------
+
+Description: Some builder for some apps
+
+Stack: test.stack.id
+
+Created: 2019-04-01T12:00:00Z by pack 0.2.0
+Build Image: some/build-image@sha256:abc
+
+Run Images:
+`)
+			})
+		})
+
 		when("is successful", func() {
 			it.Before(func() {
 				buildpacks := []pack.BuildpackInfo{
-					{ID: "test.bp.one", Version: "1.0.0", Latest: true},
+					{
+						ID:          "test.bp.one",
+						Version:     "1.0.0",
+						Latest:      true,
+						Name:        "Test Buildpack One",
+						Description: "Provides one thing",
+						Homepage:    "https://example.com/one",
+						Stacks:      []string{"test.stack.id", "other.stack.id"},
+					},
 					{ID: "test.bp.two", Version: "2.0.0", Latest: false},
 				}
 				remoteInfo := &pack.BuilderInfo{
@@ -118,7 +163,7 @@ ERROR: failed to inspect image 'some/image': some local error
 					RunImageMirrors:      []string{"first/default", "second/default"},
 					LocalRunImageMirrors: []string{"first/image", "second/image"},
 					Buildpacks:           buildpacks,
-					Groups:               [][]pack.BuildpackInfo{buildpacks},
+					Groups:               []pack.BuildpackGroupInfo{{Buildpacks: buildpacks}},
 				}
 				mockInspector.EXPECT().InspectBuilder("some/image", false).Return(remoteInfo, nil)
 
@@ -128,7 +173,11 @@ ERROR: failed to inspect image 'some/image': some local error
 					RunImageMirrors:      []string{"first/local-default", "second/local-default"},
 					LocalRunImageMirrors: []string{"first/local", "second/local"},
 					Buildpacks:           buildpacks,
-					Groups:               [][]pack.BuildpackInfo{{buildpacks[0]}, {buildpacks[1]}},
+					Groups: []pack.BuildpackGroupInfo{
+						{Buildpacks: buildpacks[:1]},
+						{Buildpacks: []pack.BuildpackInfo{{ID: "test.bp.two", Version: "2.0.0", Optional: true}}},
+					},
+					LifecycleVersion: "0.2.0",
 				}
 				mockInspector.EXPECT().InspectBuilder("some/image", true).Return(localInfo, nil)
 			})
@@ -163,9 +212,9 @@ Run Images:
   second/default
 
 Buildpacks:
-  ID                 VERSION        LATEST        
-  test.bp.one        1.0.0          true          
-  test.bp.two        2.0.0          false
+  ID                 VERSION        LATEST        NAME                      
+  test.bp.one        1.0.0          true          Test Buildpack One        
+  test.bp.two        2.0.0          false         -
 
 Detection Order:
   Group #1:
@@ -179,6 +228,8 @@ Local
 
 Stack: test.stack.id
 
+Lifecycle: 0.2.0
+
 Run Images:
   first/local (user-configured)
   second/local (user-configured)
@@ -187,19 +238,174 @@ Run Images:
   second/local-default
 
 Buildpacks:
-  ID                 VERSION        LATEST        
-  test.bp.one        1.0.0          true          
-  test.bp.two        2.0.0          false
+  ID                 VERSION        LATEST        NAME                      
+  test.bp.one        1.0.0          true          Test Buildpack One        
+  test.bp.two        2.0.0          false         -
 
 Detection Order:
   Group #1:
     test.bp.one@1.0.0
   Group #2:
-    test.bp.two@2.0.0
+    test.bp.two@2.0.0 (optional)
 `)
 			})
 		})
 
+		when("--buildpack is provided", func() {
+			it.Before(func() {
+				mockInspector.EXPECT().InspectBuilder("some/image", false).Return(&pack.BuilderInfo{
+					Stack: "test.stack.id",
+					Buildpacks: []pack.BuildpackInfo{
+						{
+							ID:          "test.bp.one",
+							Version:     "1.0.0",
+							Latest:      true,
+							Name:        "Test Buildpack One",
+							Description: "Provides one thing",
+							Homepage:    "https://example.com/one",
+							Stacks:      []string{"test.stack.id", "other.stack.id"},
+							Commit:      "0123456789abcdef0123456789abcdef01234567",
+						},
+						{ID: "test.bp.two", Version: "2.0.0"},
+					},
+				}, nil)
+				mockInspector.EXPECT().InspectBuilder("some/image", true).Return(&pack.BuilderInfo{
+					Stack:      "test.stack.id",
+					Buildpacks: []pack.BuildpackInfo{{ID: "test.bp.one", Version: "0.9.0", Latest: true}},
+				}, nil)
+			})
+
+			it("shows the details of that buildpack", func() {
+				command.SetArgs([]string{"some/image", "--buildpack", "test.bp.one"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), `Remote
+------
+
+ID: test.bp.one
+Version: 1.0.0
+Latest: true
+Name: Test Buildpack One
+Description: Provides one thing
+Homepage: https://example.com/one
+Commit: 0123456789abcdef0123456789abcdef01234567
+Stacks:
+  test.stack.id
+  other.stack.id
+
+Local
+-----
+
+ID: test.bp.one
+Version: 0.9.0
+Latest: true
+Name: -
+Description: -
+Homepage: -
+Stacks: -
+`)
+			})
+
+			it("reports a buildpack that is not in the builder", func() {
+				command.SetArgs([]string{"some/image", "--buildpack", "test.bp.missing"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "Buildpack 'test.bp.missing' not found in 'some/image'")
+			})
+
+			it("only includes that buildpack in structured output", func() {
+				command.SetArgs([]string{"some/image", "--buildpack", "test.bp.two", "--output", "json"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), `"id": "test.bp.two"`)
+				h.AssertNotContains(t, outBuf.String(), `"id": "test.bp.one"`)
+			})
+		})
+
+		when("--output is provided", func() {
+			it.Before(func() {
+				mockInspector.EXPECT().InspectBuilder("some/image", false).Return(&pack.BuilderInfo{
+					Stack:           "test.stack.id",
+					RunImage:        "some/run-image",
+					RunImageMirrors: []string{"first/default"},
+					Buildpacks:      []pack.BuildpackInfo{{ID: "test.bp.one", Version: "1.0.0", Latest: true}},
+					Groups: []pack.BuildpackGroupInfo{
+						{Buildpacks: []pack.BuildpackInfo{{ID: "test.bp.one", Version: "1.0.0", Latest: true}}},
+					},
+				}, nil)
+				mockInspector.EXPECT().InspectBuilder("some/image", true).Return(nil, nil)
+			})
+
+			it("prints json", func() {
+				command.SetArgs([]string{"some/image", "--output", "json"})
+				h.AssertNil(t, command.Execute())
+				h.AssertEq(t, outBuf.String(), `{
+  "builder_name": "some/image",
+  "remote": {
+    "stack": "test.stack.id",
+    "run_image": "some/run-image",
+    "run_image_mirrors": [
+      "first/default"
+    ],
+    "local_run_image_mirrors": null,
+    "buildpacks": [
+      {
+        "id": "test.bp.one",
+        "version": "1.0.0",
+        "latest": true
+      }
+    ],
+    "groups": [
+      {
+        "buildpacks": [
+          {
+            "id": "test.bp.one",
+            "version": "1.0.0",
+            "latest": true
+          }
+        ]
+      }
+    ]
+  },
+  "local": null
+}
+`)
+			})
+
+			it("prints yaml", func() {
+				command.SetArgs([]string{"some/image", "--output", "yaml"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), `builder_name: some/image
+remote:
+  stack: test.stack.id
+  run_image: some/run-image
+  run_image_mirrors:
+  - first/default
+`)
+				h.AssertContains(t, outBuf.String(), "local: null\n")
+			})
+
+			it("prints toml", func() {
+				command.SetArgs([]string{"some/image", "--output", "toml"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), `builder_name = "some/image"
+
+[remote]
+  stack = "test.stack.id"
+  run_image = "some/run-image"
+  run_image_mirrors = ["first/default"]
+`)
+				h.AssertContains(t, outBuf.String(), `
+    [[remote.groups.buildpacks]]
+      id = "test.bp.one"
+`)
+			})
+		})
+
+		when("--output is invalid", func() {
+			it("returns an error", func() {
+				command.SetArgs([]string{"some/image", "--output", "xml"})
+				h.AssertError(t, command.Execute(), "invalid output format 'xml', must be one of json, yaml or toml")
+			})
+		})
+
 		when("default builder is not set", func() {
 			it("informs the user", func() {
 				command.SetArgs([]string{})
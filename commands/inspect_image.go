@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+//go:generate mockgen -package mocks -destination mocks/inspect_image.go github.com/buildpack/pack/commands ImageInspector
+type ImageInspector interface {
+	InspectImage(string, bool) (*pack.ImageInfo, error)
+}
+
+type imageOutput struct {
+	ImageName string          `json:"image_name" yaml:"image_name" toml:"image_name"`
+	Remote    *pack.ImageInfo `json:"remote" yaml:"remote" toml:"remote"`
+	Local     *pack.ImageInfo `json:"local" yaml:"local" toml:"local"`
+}
+
+func InspectImage(logger *logging.Logger, inspector ImageInspector) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "inspect-image <image-name>",
+		Short: "Show information about a built image",
+		Args:  cobra.ExactArgs(1),
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputFormat(output); err != nil {
+				return err
+			}
+
+			imageName := args[0]
+			if output != "" {
+				return writeOutput(logger.RawWriter(), output, imageOutput{
+					ImageName: imageName,
+					Remote:    inspectImageInfo(logger, inspector, imageName, false),
+					Local:     inspectImageInfo(logger, inspector, imageName, true),
+				})
+			}
+
+			logger.Info("Inspecting image: %s\n", style.Symbol(imageName))
+
+			logger.Info("Remote\n------\n")
+			inspectImageOutput(logger, inspector, imageName, false)
+
+			logger.Info("\nLocal\n-----\n")
+			inspectImageOutput(logger, inspector, imageName, true)
+
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", outputFlagHelp)
+	AddHelpFlag(cmd, "inspect-image")
+	return cmd
+}
+
+func inspectImageInfo(logger *logging.Logger, inspector ImageInspector, imageName string, local bool) *pack.ImageInfo {
+	info, err := inspector.InspectImage(imageName, local)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to inspect image %s", style.Symbol(imageName)).Error())
+		return nil
+	}
+	return info
+}
+
+func inspectImageOutput(logger *logging.Logger, inspector ImageInspector, imageName string, local bool) {
+	info, err := inspector.InspectImage(imageName, local)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to inspect image %s", style.Symbol(imageName)).Error())
+		return
+	}
+
+	if info == nil {
+		logger.Info("Not present")
+		return
+	}
+
+	logger.Info("Stack: %s\n", info.Stack)
+
+	logger.Info("Base Image:")
+	logger.Info("  Top Layer: %s", info.RunImageTopLayer)
+	if info.RunImageDigest != "" {
+		logger.Info("  Digest: %s", info.RunImageDigest)
+	}
+
+	logger.Info("\nRun Images:")
+	for _, r := range info.LocalRunImageMirrors {
+		logger.Info("  %s (user-configured)", r)
+	}
+	logger.Info("  %s", info.RunImage)
+	for _, r := range info.RunImageMirrors {
+		logger.Info("  %s", r)
+	}
+
+	if len(info.Buildpacks) == 0 {
+		logger.Info("\nBuildpacks:\n  (none)")
+	} else {
+		logImageBuildpacksInfo(logger, info)
+	}
+
+	logger.Info("\nLayers:")
+	logger.Info("  App: %s", info.AppLayerSHA)
+	logger.Info("  Config: %s", info.ConfigLayerSHA)
+	logger.Info("  Launcher: %s", info.LauncherLayerSHA)
+}
+
+func logImageBuildpacksInfo(logger *logging.Logger, info *pack.ImageInfo) {
+	logger.Info("\nBuildpacks:")
+	for _, bp := range info.Buildpacks {
+		logger.Info("  %s@%s", bp.ID, bp.Version)
+		if len(bp.Layers) == 0 {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		tabWriter := new(tabwriter.Writer).Init(buf, 0, 0, 4, ' ', 0)
+		if _, err := fmt.Fprint(tabWriter, "    LAYER\tSHA\tBUILD\tLAUNCH\tCACHE\t"); err != nil {
+			logger.Error(err.Error())
+		}
+		for _, layer := range bp.Layers {
+			if _, err := fmt.Fprintf(tabWriter, "\n    %s\t%s\t%t\t%t\t%t\t", layer.Name, layer.SHA, layer.Build, layer.Launch, layer.Cache); err != nil {
+				logger.Error(err.Error())
+			}
+		}
+		if err := tabWriter.Flush(); err != nil {
+			logger.Error(err.Error())
+		}
+		logger.Info(buf.String())
+	}
+}
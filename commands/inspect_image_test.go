@@ -0,0 +1,163 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/commands"
+	cmdmocks "github.com/buildpack/pack/commands/mocks"
+	"github.com/buildpack/pack/logging"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestInspectImageCommand(t *testing.T) {
+	spec.Run(t, "Commands", testInspectImageCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testInspectImageCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		command        *cobra.Command
+		logger         *logging.Logger
+		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockInspector  *cmdmocks.MockImageInspector
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockInspector = cmdmocks.NewMockImageInspector(mockController)
+		logger = logging.NewLogger(&outBuf, &outBuf, false, false)
+		command = commands.InspectImage(logger, mockInspector)
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#InspectImage", func() {
+		when("image cannot be found", func() {
+			it("logs 'Not present'", func() {
+				mockInspector.EXPECT().InspectImage("some/image", false).Return(nil, nil)
+				mockInspector.EXPECT().InspectImage("some/image", true).Return(nil, nil)
+
+				command.SetArgs([]string{"some/image"})
+				h.AssertNil(t, command.Execute())
+
+				h.AssertContains(t, outBuf.String(), "Remote\n------\n\nNot present\n\nLocal\n-----\n\nNot present\n")
+			})
+		})
+
+		when("inspector returns an error", func() {
+			it("logs the error message", func() {
+				mockInspector.EXPECT().InspectImage("some/image", false).Return(nil, errors.New("some remote error"))
+				mockInspector.EXPECT().InspectImage("some/image", true).Return(nil, errors.New("some local error"))
+
+				command.SetArgs([]string{"some/image"})
+				h.AssertNil(t, command.Execute())
+
+				h.AssertContains(t, outBuf.String(), `Remote
+------
+
+ERROR: failed to inspect image 'some/image': some remote error
+
+Local
+-----
+
+ERROR: failed to inspect image 'some/image': some local error
+`)
+			})
+		})
+
+		when("is successful", func() {
+			it.Before(func() {
+				mockInspector.EXPECT().InspectImage("some/image", false).Return(&pack.ImageInfo{
+					Stack:                "test.stack.id",
+					RunImage:             "some/run-image",
+					RunImageMirrors:      []string{"first/default"},
+					LocalRunImageMirrors: []string{"first/local"},
+					RunImageTopLayer:     "sha256:top-layer",
+					RunImageDigest:       "sha256:run-digest",
+					Buildpacks: []pack.ImageBuildpackInfo{
+						{
+							ID:      "test.bp.one",
+							Version: "1.0.0",
+							Layers: []pack.ImageLayerInfo{
+								{Name: "node_modules", SHA: "sha256:modules", Launch: true, Cache: true},
+								{Name: "nodejs", SHA: "sha256:nodejs", Build: true, Launch: true},
+							},
+						},
+						{ID: "test.bp.two", Version: "2.0.0"},
+					},
+					AppLayerSHA:      "sha256:app",
+					ConfigLayerSHA:   "sha256:config",
+					LauncherLayerSHA: "sha256:launcher",
+				}, nil)
+				mockInspector.EXPECT().InspectImage("some/image", true).Return(nil, nil)
+			})
+
+			it("prints json when --output json is provided", func() {
+				command.SetArgs([]string{"some/image", "--output", "json"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), `{
+  "image_name": "some/image",
+  "remote": {
+    "stack": "test.stack.id",
+    "run_image": "some/run-image",`)
+				h.AssertContains(t, outBuf.String(), `
+        "layers": [
+          {
+            "name": "node_modules",
+            "sha": "sha256:modules",
+            "build": false,
+            "launch": true,
+            "cache": true
+          },`)
+				h.AssertContains(t, outBuf.String(), `"launcher_layer_sha": "sha256:launcher"
+  },
+  "local": null
+}
+`)
+			})
+
+			it("displays image information", func() {
+				command.SetArgs([]string{"some/image"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "Inspecting image: 'some/image'")
+				h.AssertContains(t, outBuf.String(), `
+Remote
+------
+
+Stack: test.stack.id
+
+Base Image:
+  Top Layer: sha256:top-layer
+  Digest: sha256:run-digest
+
+Run Images:
+  first/local (user-configured)
+  some/run-image
+  first/default
+
+Buildpacks:
+  test.bp.one@1.0.0
+    LAYER           SHA               BUILD    LAUNCH    CACHE    
+    node_modules    sha256:modules    false    true      true     
+    nodejs          sha256:nodejs     true     true      false
+  test.bp.two@2.0.0
+
+Layers:
+  App: sha256:app
+  Config: sha256:config
+  Launcher: sha256:launcher
+`)
+			})
+		})
+	})
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/buildpack/pack/style"
+)
+
+const outputFlagHelp = "Output format (json, yaml or toml), defaults to human-readable text"
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case "", "json", "yaml", "toml":
+		return nil
+	}
+	return fmt.Errorf("invalid output format %s, must be one of json, yaml or toml", style.Symbol(format))
+}
+
+func writeOutput(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(v)
+	case "toml":
+		return toml.NewEncoder(w).Encode(v)
+	}
+	return validateOutputFormat(format)
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/docker"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+func PackageBuildpack(logger *logging.Logger, bpFetcher pack.BuildpackFetcher) *cobra.Command {
+	var flags pack.PackageBuildpackFlags
+	ctx := createCancellableContext()
+	cmd := &cobra.Command{
+		Use:   "package-buildpack <output> --config <package-config-path>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Package buildpacks into a .cnb archive or an image",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			flags.Output = args[0]
+			if runtime.GOOS == "windows" {
+				return fmt.Errorf("%s is not implemented on Windows", style.Symbol("package-buildpack"))
+			}
+
+			packageFactory := pack.PackageFactory{
+				Logger:           logger,
+				BuildpackFetcher: bpFetcher,
+			}
+			if flags.Image {
+				dockerClient, err := docker.New()
+				if err != nil {
+					return err
+				}
+				packageFactory.Docker = dockerClient
+			}
+			packageConfig, err := packageFactory.PackageBuildpackConfigFromFlags(flags)
+			if err != nil {
+				return err
+			}
+			if err := packageFactory.Package(ctx, packageConfig); err != nil {
+				return err
+			}
+			logger.Info("Successfully packaged buildpacks as %s", style.Symbol(flags.Output))
+			uri := flags.Output
+			if flags.Image {
+				uri = "docker://" + flags.Output
+			}
+			logger.Tip("Use %s in the %s of a builder.toml", style.Symbol(fmt.Sprintf("uri = %q", uri)), style.Symbol("[[buildpacks]]"))
+			return nil
+		}),
+	}
+	cmd.Flags().StringVarP(&flags.ConfigPath, "config", "c", "", "Path to package TOML file (required)")
+	cmd.MarkFlagRequired("config")
+	cmd.Flags().BoolVar(&flags.Image, "image", false, "Create an image named <output> instead of an archive")
+	AddHelpFlag(cmd, "package-buildpack")
+	return cmd
+}
@@ -1,24 +1,44 @@
 package commands
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/buildpack/pack"
 	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/docker"
 	"github.com/buildpack/pack/logging"
 	"github.com/buildpack/pack/style"
 )
 
 func Rebase(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
-	var flags pack.RebaseFlags
+	var (
+		flags    pack.RebaseFlags
+		fromFile string
+		jobs     int
+		check    bool
+	)
 	ctx := createCancellableContext()
 
 	cmd := &cobra.Command{
-		Use:   "rebase <image-name>",
-		Args:  cobra.ExactArgs(1),
+		Use:   "rebase <image-name>...",
 		Short: "Rebase app image with latest run image",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if fromFile == "" && len(args) == 0 {
+				return errors.New("at least one image name or --from-file must be provided")
+			}
+			if check && (flags.Tag != "" || flags.BackupTag != "") {
+				return errors.New("--tag and --backup-tag cannot be used with --check, which does not modify images")
+			}
+			return nil
+		},
 		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
-			flags.RepoName = args[0]
 			cfg, err := config.NewDefault()
 			if err != nil {
 				return err
@@ -28,20 +48,134 @@ func Rebase(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
 				Config:  cfg,
 				Fetcher: fetcher,
 			}
-			rebaseConfig, err := factory.RebaseConfigFromFlags(ctx, flags)
-			if err != nil {
-				return err
+			// the daemon is only asked for the IDs of the images it rebases
+			if !flags.Publish && !check {
+				if factory.Docker, err = docker.New(); err != nil {
+					return err
+				}
 			}
-			if err := factory.Rebase(rebaseConfig); err != nil {
-				return err
+
+			repoNames := args
+			if fromFile != "" {
+				names, err := readImageNames(fromFile)
+				if err != nil {
+					return err
+				}
+				repoNames = append(repoNames, names...)
 			}
-			logger.Info("Successfully rebased image %s", style.Symbol(rebaseConfig.Image.Name()))
-			return nil
+
+			if len(repoNames) > 1 && (flags.Tag != "" || flags.BackupTag != "") {
+				return errors.New("--tag and --backup-tag can only be used when rebasing a single image")
+			}
+
+			if check {
+				return checkRebase(logger, factory, repoNames, flags)
+			}
+
+			if len(repoNames) == 1 && fromFile == "" {
+				flags.RepoName = repoNames[0]
+				rebaseConfig, err := factory.RebaseConfigFromFlags(ctx, flags)
+				if err != nil {
+					return err
+				}
+				if err := factory.Rebase(rebaseConfig); err != nil {
+					return err
+				}
+				logger.Info("Successfully rebased image %s", style.Symbol(rebaseConfig.Image.Name()))
+				return nil
+			}
+
+			results := factory.RebaseAll(ctx, repoNames, flags, jobs)
+			return printRebaseResults(logger, results)
 		}),
 	}
 	cmd.Flags().BoolVar(&flags.Publish, "publish", false, "Publish to registry")
 	cmd.Flags().BoolVar(&flags.NoPull, "no-pull", false, "Skip pulling app and run images before use")
 	cmd.Flags().StringVar(&flags.RunImage, "run-image", "", "Run image to use for rebasing")
+	cmd.Flags().StringVar(&flags.Tag, "tag", "", "Save the rebased image under this name instead of overwriting the original")
+	cmd.Flags().StringVar(&flags.BackupTag, "backup-tag", "", "Save the image as it was before rebasing under this name")
+	cmd.Flags().BoolVar(&flags.Force, "force", false, "Rebase even if the run image's stack does not match the app image's stack")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "File containing image names to rebase, one per line")
+	cmd.Flags().IntVar(&jobs, "jobs", 4, "Number of images to rebase concurrently")
+	cmd.Flags().BoolVar(&check, "check", false, "Report whether images need to be rebased without modifying them\nExits with a non-zero status if any image is stale")
 	AddHelpFlag(cmd, "rebase")
 	return cmd
 }
+
+func checkRebase(logger *logging.Logger, factory pack.RebaseFactory, repoNames []string, flags pack.RebaseFlags) error {
+	stale, failed := false, 0
+	for _, status := range factory.CheckAll(repoNames, flags) {
+		switch {
+		case status.Err != nil:
+			failed++
+			logger.Error("Failed to check image %s: %s", style.Symbol(status.RepoName), status.Err)
+		case status.Stale:
+			stale = true
+			logger.Info("Image %s is stale: run image %s has changed since it was built", style.Symbol(status.RepoName), style.Symbol(status.RunImage))
+			logger.Verbose("  current run image: digest %s, top layer %s", style.Symbol(status.CurrentSHA), style.Symbol(status.CurrentTopLayer))
+			logger.Verbose("  latest run image:  digest %s, top layer %s", style.Symbol(status.LatestSHA), style.Symbol(status.LatestTopLayer))
+		default:
+			logger.Info("Image %s is up to date with run image %s", style.Symbol(status.RepoName), style.Symbol(status.RunImage))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to check %d of %d images", failed, len(repoNames))
+	}
+	if stale {
+		return MakeSoftError()
+	}
+	return nil
+}
+
+func readImageNames(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading image names from %s", style.Symbol(path))
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "reading image names from %s", style.Symbol(path))
+	}
+	return names, nil
+}
+
+func printRebaseResults(logger *logging.Logger, results []pack.RebaseResult) error {
+	tw := tabwriter.NewWriter(logger.RawWriter(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "IMAGE\tOLD DIGEST\tNEW DIGEST\tERROR")
+
+	failed := 0
+	for _, result := range results {
+		errMsg := ""
+		if result.Err != nil {
+			errMsg = result.Err.Error()
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.RepoName, valueOrDash(result.OldDigest), valueOrDash(result.NewDigest), errMsg)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to rebase %d of %d images", failed, len(results))
+	}
+	logger.Info("Successfully rebased %d images", len(results))
+	return nil
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+package commands_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack/commands"
+	"github.com/buildpack/pack/logging"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestRebaseCommand(t *testing.T) {
+	spec.Run(t, "Commands", testRebaseCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testRebaseCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		command *cobra.Command
+		outBuf  bytes.Buffer
+	)
+
+	it.Before(func() {
+		command = commands.Rebase(logging.NewLogger(&outBuf, &outBuf, false, false), nil)
+	})
+
+	when("#Rebase", func() {
+		it("rejects --tag with --check", func() {
+			command.SetArgs([]string{"--check", "--tag", "some/app:rebased", "some/app"})
+			h.AssertError(t, command.Execute(), "--tag and --backup-tag cannot be used with --check")
+		})
+
+		it("rejects --backup-tag with --check", func() {
+			command.SetArgs([]string{"--check", "--backup-tag", "some/app:backup", "some/app"})
+			h.AssertError(t, command.Execute(), "--tag and --backup-tag cannot be used with --check")
+		})
+	})
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/buildpack/pack/logging"
 )
 
-func Run(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
+func Run(logger *logging.Logger, fetcher pack.Fetcher, bpFetcher pack.BuildpackFetcher) *cobra.Command {
 	var runFlags pack.RunFlags
 	ctx := createCancellableContext()
 
@@ -34,6 +34,7 @@ func Run(logger *logging.Logger, fetcher pack.Fetcher) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			bf.BuildpackFetcher = bpFetcher
 
 			if bf.Config.DefaultBuilder == "" && runFlags.BuildFlags.Builder == "" {
 				suggestSettingBuilder(logger)
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/sbom"
+	"github.com/buildpack/pack/style"
+)
+
+//go:generate mockgen -package mocks -destination mocks/bom_generator.go github.com/buildpack/pack/commands BOMGenerator
+type BOMGenerator interface {
+	GenerateBOM(string, bool) (*sbom.BOM, error)
+}
+
+func SBOM(logger *logging.Logger, generator BOMGenerator) *cobra.Command {
+	var (
+		format     string
+		outputFile string
+		remote     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "sbom <image-name>",
+		Short: "Export the software bill of materials of an app image",
+		Args:  cobra.ExactArgs(1),
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			if format != sbom.FormatSPDX && format != sbom.FormatCycloneDX {
+				return errors.Errorf("unknown SBOM format %s, must be one of %s or %s", style.Symbol(format), sbom.FormatSPDX, sbom.FormatCycloneDX)
+			}
+
+			bom, err := generator.GenerateBOM(args[0], !remote)
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = logger.RawWriter()
+			if outputFile != "" {
+				file, err := os.Create(outputFile)
+				if err != nil {
+					return errors.Wrapf(err, "creating SBOM file %s", style.Symbol(outputFile))
+				}
+				defer file.Close()
+				w = file
+			}
+			return bom.Write(w, format)
+		}),
+	}
+	cmd.Flags().StringVar(&format, "format", sbom.FormatSPDX, "SBOM format (spdx or cyclonedx)")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "File to write the SBOM to (defaults to stdout)")
+	cmd.Flags().BoolVar(&remote, "remote", false, "Read the image from the registry instead of the local daemon")
+	AddHelpFlag(cmd, "sbom")
+	return cmd
+}
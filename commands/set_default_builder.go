@@ -8,7 +8,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func SetDefaultBuilder(logger *logging.Logger) *cobra.Command {
+func SetDefaultBuilder(logger *logging.Logger, inspector BuilderInspector) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "set-default-builder <builder-name>",
 		Short: "Set default builder used by other commands",
@@ -30,6 +30,9 @@ func SetDefaultBuilder(logger *logging.Logger) *cobra.Command {
 				return err
 			}
 			logger.Info("Builder %s is now the default builder", style.Symbol(args[0]))
+			if description := builderDescription(inspector, args[0]); description != "" {
+				logger.Info("  %s", description)
+			}
 			return nil
 		}),
 	}
@@ -37,3 +40,14 @@ func SetDefaultBuilder(logger *logging.Logger) *cobra.Command {
 	AddHelpFlag(cmd, "set-default-builder")
 	return cmd
 }
+
+// builderDescription returns the description of the builder, looking in the daemon before the registry. The
+// description is informational, so a builder that cannot be inspected simply has none.
+func builderDescription(inspector BuilderInspector, imageName string) string {
+	for _, daemon := range []bool{true, false} {
+		if info, err := inspector.InspectBuilder(imageName, daemon); err == nil && info != nil {
+			return info.Description
+		}
+	}
+	return ""
+}
@@ -2,13 +2,18 @@ package commands_test
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 	"github.com/spf13/cobra"
 
+	"github.com/buildpack/pack"
 	"github.com/buildpack/pack/commands"
+	cmdmocks "github.com/buildpack/pack/commands/mocks"
 	"github.com/buildpack/pack/logging"
 	h "github.com/buildpack/pack/testhelpers"
 )
@@ -23,11 +28,19 @@ func testSetDefaultBuilderCommand(t *testing.T, when spec.G, it spec.S) {
 		command        *cobra.Command
 		logger         *logging.Logger
 		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockInspector  *cmdmocks.MockBuilderInspector
 	)
 
 	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockInspector = cmdmocks.NewMockBuilderInspector(mockController)
 		logger = logging.NewLogger(&outBuf, &outBuf, false, false)
-		command = commands.SetDefaultBuilder(logger)
+		command = commands.SetDefaultBuilder(logger, mockInspector)
+	})
+
+	it.After(func() {
+		mockController.Finish()
 	})
 
 	when("#SetDefaultBuilder", func() {
@@ -46,5 +59,40 @@ func testSetDefaultBuilderCommand(t *testing.T, when spec.G, it spec.S) {
 				h.AssertContains(t, outBuf.String(), "Suggested builders:")
 			})
 		})
+
+		when("a builder name is provided", func() {
+			var packHome string
+
+			it.Before(func() {
+				var err error
+				packHome, err = ioutil.TempDir("", "pack-home")
+				h.AssertNil(t, err)
+				h.AssertNil(t, os.Setenv("PACK_HOME", packHome))
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.Unsetenv("PACK_HOME"))
+				h.AssertNil(t, os.RemoveAll(packHome))
+			})
+
+			it("shows the description of the builder", func() {
+				mockInspector.EXPECT().InspectBuilder("some/builder", true).Return(&pack.BuilderInfo{
+					Description: "Some builder for some apps",
+				}, nil)
+
+				command.SetArgs([]string{"some/builder"})
+				h.AssertNil(t, command.Execute())
+				h.AssertEq(t, outBuf.String(), "Builder 'some/builder' is now the default builder\n  Some builder for some apps\n")
+			})
+
+			it("looks for the builder in the registry when it is not in the daemon", func() {
+				mockInspector.EXPECT().InspectBuilder("some/builder", true).Return(nil, nil)
+				mockInspector.EXPECT().InspectBuilder("some/builder", false).Return(nil, nil)
+
+				command.SetArgs([]string{"some/builder"})
+				h.AssertNil(t, command.Execute())
+				h.AssertEq(t, outBuf.String(), "Builder 'some/builder' is now the default builder\n")
+			})
+		})
 	})
 }
@@ -2,11 +2,16 @@ package pack
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpack/lifecycle"
@@ -23,19 +28,31 @@ import (
 )
 
 type BuilderConfig struct {
-	Buildpacks      []buildpack.Buildpack
-	Groups          []lifecycle.BuildpackGroup
-	Repo            lcimg.Image
-	BuilderDir      string // original location of builder.toml, used for interpreting relative paths in buildpack URIs
-	RunImage        string
-	RunImageMirrors []string
+	Buildpacks       []buildpack.Buildpack
+	Groups           []lifecycle.BuildpackGroup
+	Repo             lcimg.Image
+	BuilderDir       string // original location of builder.toml, used for interpreting relative paths in buildpack URIs
+	StackID          string
+	RunImage         string
+	RunImageMirrors  []string
+	DryRun           bool
+	LifecycleDir     string // directory containing the lifecycle binaries to embed, if builder.toml selects a lifecycle
+	LifecycleVersion string
+	Description      string
+	Metadata         map[string]interface{} // arbitrary metadata from builder.toml, recorded as is
+	BuildImage       string
+	BuildImageDigest string
+	Created          time.Time
+	Previous         *builder.Metadata // metadata of an existing builder with the same name, whose layers may be reused
 }
 
 type BuilderFactory struct {
+	PackVersion      string
 	Logger           *logging.Logger
 	Config           *config.Config
 	Fetcher          Fetcher
 	BuildpackFetcher BuildpackFetcher
+	Docker           Docker
 }
 
 type CreateBuilderFlags struct {
@@ -43,6 +60,8 @@ type CreateBuilderFlags struct {
 	BuilderTomlPath string
 	Publish         bool
 	NoPull          bool
+	DryRun          bool
+	UseCurrentTime  bool
 }
 
 func (f *BuilderFactory) BuilderConfigFromFlags(ctx context.Context, flags CreateBuilderFlags) (BuilderConfig, error) {
@@ -60,9 +79,16 @@ func (f *BuilderFactory) BuilderConfigFromFlags(ctx context.Context, flags Creat
 	}
 
 	baseImage := builderTOML.Stack.BuildImage
+	builderConfig.Description = builderTOML.Description
+	builderConfig.Metadata = builderTOML.Metadata
+	builderConfig.BuildImage = baseImage
+	builderConfig.StackID = builderTOML.Stack.ID
 	builderConfig.RunImage = builderTOML.Stack.RunImage
 	builderConfig.RunImageMirrors = builderTOML.Stack.RunImageMirrors
-	if flags.Publish {
+	builderConfig.DryRun = flags.DryRun
+	if flags.DryRun {
+		// nothing is saved, so there is no need to fetch the base image
+	} else if flags.Publish {
 		builderConfig.Repo, err = f.Fetcher.FetchRemoteImage(baseImage)
 	} else {
 		if !flags.NoPull {
@@ -74,10 +100,35 @@ func (f *BuilderFactory) BuilderConfigFromFlags(ctx context.Context, flags Creat
 	if err != nil {
 		return BuilderConfig{}, errors.Wrapf(err, "opening base image: %s", baseImage)
 	}
-	builderConfig.Repo.Rename(flags.RepoName)
+	if builderConfig.Repo != nil {
+		builderConfig.BuildImageDigest, err = builderConfig.Repo.Digest()
+		if err != nil {
+			return BuilderConfig{}, errors.Wrapf(err, "reading digest of base image: %s", baseImage)
+		}
+		builderConfig.Created, err = creationTime(flags.UseCurrentTime)
+		if err != nil {
+			return BuilderConfig{}, err
+		}
+		builderConfig.Repo = newBuilderImage(builderConfig.Repo, baseImage, builderConfig.Created, flags.Publish, f.Docker)
+		builderConfig.Repo.Rename(flags.RepoName)
+		builderConfig.Previous = f.previousBuilderMetadata(flags.RepoName, flags.Publish)
+	}
 
 	builderConfig.Groups = builderTOML.Groups
 
+	if lc := builderTOML.Lifecycle; lc.Version != "" || lc.URI != "" {
+		builderConfig.LifecycleVersion = lc.Version
+		builderConfig.LifecycleDir, err = f.fetchLifecycle(builderConfig.BuilderDir, lc)
+		if err != nil {
+			return BuilderConfig{}, err
+		}
+		if builderConfig.LifecycleVersion == "" {
+			if builderConfig.LifecycleVersion, err = readLifecycleVersion(builderConfig.LifecycleDir); err != nil {
+				return BuilderConfig{}, err
+			}
+		}
+	}
+
 	for _, b := range builderTOML.Buildpacks {
 		fetchedBuildpack, err := f.BuildpackFetcher.FetchBuildpack(builderConfig.BuilderDir, b)
 		if err != nil {
@@ -95,6 +146,43 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 	}
 	defer os.RemoveAll(tmpDir)
 
+	buildpacksData, err := f.readBuildpacks(config.Buildpacks)
+	if err != nil {
+		return err
+	}
+	if err := validateBuildpackStacks(config.StackID, buildpacksData); err != nil {
+		return err
+	}
+	unused, err := validateGroups(config.Groups, config.Buildpacks, buildpacksData)
+	if err != nil {
+		return err
+	}
+	for _, ref := range unused {
+		f.Logger.Info("Warning: buildpack %s is not used by any group", style.Symbol(ref))
+	}
+
+	lifecycleDir := ""
+	if config.LifecycleDir != "" {
+		if lifecycleDir, err = findLifecycleBinaries(config.LifecycleDir); err != nil {
+			return err
+		}
+	}
+
+	if config.DryRun {
+		f.logDetectionOrder(config.Groups)
+		return nil
+	}
+
+	if lifecycleDir != "" {
+		lifecycleTar := filepath.Join(tmpDir, "lifecycle.tar")
+		if err := archive.CreateNormalizedTar(lifecycleTar, lifecycleDir, "/lifecycle", 0, 0); err != nil {
+			return fmt.Errorf(`failed to generate lifecycle layer: %s`, err)
+		}
+		if err := config.Repo.AddLayer(lifecycleTar); err != nil {
+			return fmt.Errorf(`failed append lifecycle layer to image: %s`, err)
+		}
+	}
+
 	orderTar, err := f.orderLayer(tmpDir, config.Groups)
 	if err != nil {
 		return fmt.Errorf(`failed to generate order.toml layer: %s`, err)
@@ -104,15 +192,17 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 	}
 
 	buildpacksMetadata := make([]builder.BuildpackMetadata, 0, len(config.Buildpacks))
-	for _, buildpack := range config.Buildpacks {
-		tarFile, err := f.buildpackLayer(tmpDir, &buildpack, config.BuilderDir)
+	for i, buildpack := range config.Buildpacks {
+		data := buildpacksData[i]
+		diffID, err := f.addBuildpackLayer(config, tmpDir, buildpack, data)
 		if err != nil {
-			return fmt.Errorf(`failed to generate layer for buildpack %s: %s`, style.Symbol(buildpack.ID), err)
-		}
-		if err := config.Repo.AddLayer(tarFile); err != nil {
-			return fmt.Errorf(`failed append buildpack layer to image: %s`, err)
+			return err
 		}
-		buildpacksMetadata = append(buildpacksMetadata, builder.BuildpackMetadata{ID: buildpack.ID, Version: buildpack.Version, Latest: buildpack.Latest})
+		md := data.metadata(buildpack.Latest)
+		md.LayerDiffID = diffID
+		md.Commit = buildpack.Commit
+		md.SourceDigest = buildpack.SourceDigest
+		buildpacksMetadata = append(buildpacksMetadata, md)
 	}
 
 	tarFile, err := f.latestLayer(config.Buildpacks, tmpDir, config.BuilderDir)
@@ -123,16 +213,15 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 		return fmt.Errorf(`failed append latest link layer to image: %s`, err)
 	}
 
-	groupsMetadata := make([]builder.GroupMetadata, 0, len(config.Groups))
-	for _, group := range config.Groups {
-		groupBuildpacks := make([]builder.BuildpackMetadata, 0, len(group.Buildpacks))
-		for _, buildpack := range group.Buildpacks {
-			groupBuildpacks = append(groupBuildpacks, builder.BuildpackMetadata{ID: buildpack.ID, Version: buildpack.Version})
-		}
-		groupsMetadata = append(groupsMetadata, builder.GroupMetadata{Buildpacks: groupBuildpacks})
-	}
-
-	jsonBytes, err := json.Marshal(&builder.Metadata{
+	metadata := builder.Metadata{
+		Description: config.Description,
+		Custom:      config.Metadata,
+		CreatedBy: &builder.CreatorMetadata{
+			PackVersion:      f.PackVersion,
+			Created:          config.Created,
+			BuildImage:       config.BuildImage,
+			BuildImageDigest: config.BuildImageDigest,
+		},
 		Stack: stack.Metadata{
 			RunImage: stack.RunImageMetadata{
 				Image:   config.RunImage,
@@ -140,8 +229,12 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 			},
 		},
 		Buildpacks: buildpacksMetadata,
-		Groups:     groupsMetadata,
-	})
+		Groups:     groupsMetadata(config.Groups),
+	}
+	if lifecycleDir != "" {
+		metadata.Lifecycle = &builder.LifecycleMetadata{Version: config.LifecycleVersion}
+	}
+	jsonBytes, err := json.Marshal(&metadata)
 	if err != nil {
 		return fmt.Errorf(`failed marshal builder image metadata: %s`, err)
 	}
@@ -169,12 +262,216 @@ func (f *BuilderFactory) Create(config BuilderConfig) error {
 	return nil
 }
 
+// previousBuilderMetadata returns the metadata of the builder image with the given name, or nil if there is no such
+// builder. A missing or unreadable previous builder only means that no layers are reused.
+func (f *BuilderFactory) previousBuilderMetadata(repoName string, publish bool) *builder.Metadata {
+	var (
+		img lcimg.Image
+		err error
+	)
+	if publish {
+		img, err = f.Fetcher.FetchRemoteImage(repoName)
+	} else {
+		img, err = f.Fetcher.FetchLocalImage(repoName)
+	}
+	if err != nil {
+		f.Logger.Verbose("Not reusing layers of previous builder %s: %s", style.Symbol(repoName), err)
+		return nil
+	}
+	if found, err := img.Found(); err != nil || !found {
+		return nil
+	}
+
+	label, err := img.Label(builder.MetadataLabel)
+	if err != nil || label == "" {
+		return nil
+	}
+	var metadata builder.Metadata
+	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+		f.Logger.Verbose("Not reusing layers of previous builder %s: %s", style.Symbol(repoName), err)
+		return nil
+	}
+	return &metadata
+}
+
+// addBuildpackLayer adds the layer of a buildpack to the builder, or reuses the layer of the previous builder when the
+// buildpack has not changed, and returns the diff ID of the layer. A buildpack fetched from the same archive, image
+// layer or commit as in the previous builder is reused without building its layer; otherwise its layer is built and
+// reused if it is identical to the previous one.
+func (f *BuilderFactory) addBuildpackLayer(config BuilderConfig, tmpDir string, bp buildpack.Buildpack, data *BuildpackData) (string, error) {
+	ref := style.Symbol(data.BP.ID + "@" + data.BP.Version)
+	if diffID := previousBuildpackLayer(config.Previous, data.BP.ID, data.BP.Version, bp.SourceDigest, ""); diffID != "" {
+		f.Logger.Verbose("Reusing layer for unchanged buildpack %s", ref)
+		if err := config.Repo.ReuseLayer(diffID); err != nil {
+			return "", fmt.Errorf(`failed to reuse buildpack layer from previous builder: %s`, err)
+		}
+		return diffID, nil
+	}
+
+	tarFile, err := f.buildpackLayer(tmpDir, bp, data)
+	if err != nil {
+		return "", fmt.Errorf(`failed to generate layer for buildpack %s: %s`, style.Symbol(bp.ID), err)
+	}
+	diffID, err := layerDiffID(tarFile)
+	if err != nil {
+		return "", fmt.Errorf(`failed to compute diff ID of buildpack layer: %s`, err)
+	}
+	if previousBuildpackLayer(config.Previous, data.BP.ID, data.BP.Version, "", diffID) != "" {
+		f.Logger.Verbose("Reusing layer for unchanged buildpack %s", ref)
+		if err := config.Repo.ReuseLayer(diffID); err != nil {
+			return "", fmt.Errorf(`failed to reuse buildpack layer from previous builder: %s`, err)
+		}
+	} else if err := config.Repo.AddLayer(tarFile); err != nil {
+		return "", fmt.Errorf(`failed append buildpack layer to image: %s`, err)
+	}
+	return diffID, nil
+}
+
+// previousBuildpackLayer returns the diff ID of the layer of the buildpack in the previous builder, if the buildpack
+// was fetched from the given source, or its layer has the given diff ID. Buildpack layers are reproducible, so either
+// means the buildpack has not changed.
+func previousBuildpackLayer(previous *builder.Metadata, id, version, sourceDigest, diffID string) string {
+	if previous == nil {
+		return ""
+	}
+	for _, bp := range previous.Buildpacks {
+		if bp.ID != id || bp.Version != version || bp.LayerDiffID == "" {
+			continue
+		}
+		if (sourceDigest != "" && bp.SourceDigest == sourceDigest) || (diffID != "" && bp.LayerDiffID == diffID) {
+			return bp.LayerDiffID
+		}
+	}
+	return ""
+}
+
+func layerDiffID(layerTar string) (string, error) {
+	file, err := os.Open(layerTar)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", hasher.Sum(nil)), nil
+}
+
+func groupsMetadata(groups []lifecycle.BuildpackGroup) []builder.GroupMetadata {
+	metadata := make([]builder.GroupMetadata, 0, len(groups))
+	for _, group := range groups {
+		groupBuildpacks := make([]builder.BuildpackMetadata, 0, len(group.Buildpacks))
+		for _, buildpack := range group.Buildpacks {
+			groupBuildpacks = append(groupBuildpacks, builder.BuildpackMetadata{ID: buildpack.ID, Version: buildpack.Version, Optional: buildpack.Optional})
+		}
+		metadata = append(metadata, builder.GroupMetadata{Buildpacks: groupBuildpacks})
+	}
+	return metadata
+}
+
+// creationTime is the time recorded as the creation time of a builder, in its metadata and image config. Unless the
+// current time is asked for, it is a fixed time or the time SOURCE_DATE_EPOCH selects, so that building the same
+// builder twice produces the same image.
+func creationTime(useCurrentTime bool) (time.Time, error) {
+	if useCurrentTime {
+		return time.Now().UTC().Truncate(time.Second), nil
+	}
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return archive.NormalizedDateTime, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %s: must be a number of seconds", style.Symbol(epoch))
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// lifecycleReleaseURI is where released versions of the lifecycle are downloaded from.
+const lifecycleReleaseURI = "https://github.com/buildpack/lifecycle/releases/download/v%[1]s/lifecycle-v%[1]s+linux.x86-64.tgz"
+
+// lifecycleBinaries are the lifecycle phases run by pack, which must all be present in an embedded lifecycle.
+var lifecycleBinaries = []string{"detector", "restorer", "analyzer", "builder", "exporter", "cacher"}
+
+// fetchLifecycle fetches the lifecycle selected in builder.toml in the same way as a buildpack, so that it can be
+// given as a path, a tarball or a URL and is cached alike.
+func (f *BuilderFactory) fetchLifecycle(builderDir string, lc builder.Lifecycle) (string, error) {
+	uri := lc.URI
+	if uri == "" {
+		uri = fmt.Sprintf(lifecycleReleaseURI, lc.Version)
+	}
+
+	fetched, err := f.BuildpackFetcher.FetchBuildpack(builderDir, buildpack.Buildpack{URI: uri, SHA256: lc.SHA256})
+	if err != nil {
+		return "", errors.Wrapf(err, "fetching lifecycle from %s", style.Symbol(uri))
+	}
+	return fetched.Dir, nil
+}
+
+// readLifecycleVersion reads the version of a lifecycle from its lifecycle.toml, which lifecycle tarballs keep next to
+// the binaries.
+func readLifecycleVersion(dir string) (string, error) {
+	for _, path := range []string{filepath.Join(dir, "lifecycle.toml"), filepath.Join(dir, "lifecycle", "lifecycle.toml")} {
+		var descriptor struct {
+			Lifecycle struct {
+				Version string `toml:"version"`
+			} `toml:"lifecycle"`
+		}
+		if _, err := toml.DecodeFile(path, &descriptor); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", errors.Wrapf(err, "reading %s", style.Symbol(path))
+		}
+		if descriptor.Lifecycle.Version != "" {
+			return descriptor.Lifecycle.Version, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine the version of the lifecycle in %s, set lifecycle.version in builder.toml", style.Symbol(dir))
+}
+
+// findLifecycleBinaries returns the directory below dir that contains the lifecycle binaries, which lifecycle
+// tarballs keep in a top-level 'lifecycle' directory.
+func findLifecycleBinaries(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "lifecycle", lifecycleBinaries[0])); err == nil {
+		dir = filepath.Join(dir, "lifecycle")
+	}
+
+	var missing []string
+	for _, name := range lifecycleBinaries {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			missing = append(missing, style.Symbol(name))
+		} else if err != nil {
+			return "", err
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("lifecycle in %s is missing %s", style.Symbol(dir), strings.Join(missing, ", "))
+	}
+	return dir, nil
+}
+
+func (f *BuilderFactory) logDetectionOrder(groups []lifecycle.BuildpackGroup) {
+	f.Logger.Info("Detection Order:")
+	for i, group := range groups {
+		f.Logger.Info("  Group #%d:", i+1)
+		for _, bp := range group.Buildpacks {
+			if bp.Optional {
+				f.Logger.Info("    %s@%s (optional)", bp.ID, bp.Version)
+			} else {
+				f.Logger.Info("    %s@%s", bp.ID, bp.Version)
+			}
+		}
+	}
+}
+
 type order struct {
 	Groups []lifecycle.BuildpackGroup `toml:"groups"`
 }
 
 func (f *BuilderFactory) orderLayer(dest string, groups []lifecycle.BuildpackGroup) (layerTar string, err error) {
-	bpDir := filepath.Join(dest, "buildpacks")
+	bpDir := filepath.Join(dest, "order-layer")
 	err = os.Mkdir(bpDir, 0755)
 	if err != nil {
 		return "", err
@@ -190,15 +487,15 @@ func (f *BuilderFactory) orderLayer(dest string, groups []lifecycle.BuildpackGro
 		return "", err
 	}
 	layerTar = filepath.Join(dest, "order.tar")
-	if err := archive.CreateTar(layerTar, bpDir, "/buildpacks", 0, 0); err != nil {
+	if err := archive.CreateNormalizedTar(layerTar, bpDir, "/buildpacks", 0, 0); err != nil {
 		return "", err
 	}
 	return layerTar, nil
 }
 
 func (f *BuilderFactory) stackLayer(dest string, runImage string, mirrors []string) (layerTar string, err error) {
-	bpDir := filepath.Join(dest, "buildpacks")
-	if err := os.MkdirAll(bpDir, 0755); err != nil {
+	bpDir := filepath.Join(dest, "stack-layer")
+	if err := os.Mkdir(bpDir, 0755); err != nil {
 		return "", err
 	}
 
@@ -219,7 +516,7 @@ func (f *BuilderFactory) stackLayer(dest string, runImage string, mirrors []stri
 	}
 
 	layerTar = filepath.Join(dest, "stack.tar")
-	if err := archive.CreateTar(layerTar, bpDir, "/buildpacks", 0, 0); err != nil {
+	if err := archive.CreateNormalizedTar(layerTar, bpDir, "/buildpacks", 0, 0); err != nil {
 		return "", err
 	}
 
@@ -228,35 +525,78 @@ func (f *BuilderFactory) stackLayer(dest string, runImage string, mirrors []stri
 
 type BuildpackData struct {
 	BP struct {
-		ID      string `toml:"id"`
-		Version string `toml:"version"`
+		ID          string `toml:"id"`
+		Version     string `toml:"version"`
+		Name        string `toml:"name"`
+		Description string `toml:"description"`
+		Homepage    string `toml:"homepage"`
 	} `toml:"buildpack"`
+	Stacks []struct {
+		ID string `toml:"id"`
+	} `toml:"stacks"`
 }
 
-// buildpackLayer creates and returns the location of a tgz file for a buildpack layer. That file will reside in the `dest` directory.
-// The tgz file is either created from an initially local directory, or it is downloaded (and validated) from
-// a remote location if the buildpack uri uses the http(s) protocol.
-func (f *BuilderFactory) buildpackLayer(dest string, buildpack *buildpack.Buildpack, builderDir string) (layerTar string, err error) {
-	dir := buildpack.Dir
+// anyStack may be given as a stack ID in buildpack.toml by buildpacks that run on any stack.
+const anyStack = "*"
 
-	data, err := f.buildpackData(*buildpack, dir)
-	if err != nil {
-		return "", err
+func (d *BuildpackData) stackIDs() []string {
+	var ids []string
+	for _, s := range d.Stacks {
+		ids = append(ids, s.ID)
 	}
-	bp := data.BP
-	if buildpack.ID != bp.ID {
-		return "", fmt.Errorf("buildpack IDs did not match: %s != %s", buildpack.ID, bp.ID)
+	return ids
+}
+
+func (d *BuildpackData) supportsStack(stackID string) bool {
+	for _, id := range d.stackIDs() {
+		if id == stackID || id == anyStack {
+			return true
+		}
 	}
-	if bp.Version == "" {
-		return "", fmt.Errorf("buildpack.toml must provide version: %s", filepath.Join(buildpack.Dir, "buildpack.toml"))
+	return false
+}
+
+func (d *BuildpackData) metadata(latest bool) builder.BuildpackMetadata {
+	md := builder.BuildpackMetadata{
+		ID:          d.BP.ID,
+		Version:     d.BP.Version,
+		Latest:      latest,
+		Name:        d.BP.Name,
+		Description: d.BP.Description,
+		Homepage:    d.BP.Homepage,
+	}
+	md.Stacks = d.stackIDs()
+	return md
+}
+
+// readBuildpacks reads the buildpack.toml of each buildpack, so that the builder can be validated before any layer
+// is built.
+func (f *BuilderFactory) readBuildpacks(buildpacks []buildpack.Buildpack) ([]*BuildpackData, error) {
+	var all []*BuildpackData
+	for _, bp := range buildpacks {
+		data, err := f.buildpackData(bp, bp.Dir)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to read buildpack %s: %s`, style.Symbol(bp.ID), err)
+		}
+		if bp.ID != data.BP.ID {
+			return nil, fmt.Errorf("buildpack IDs did not match: %s != %s", bp.ID, data.BP.ID)
+		}
+		if data.BP.Version == "" {
+			return nil, fmt.Errorf("buildpack.toml must provide version: %s", filepath.Join(bp.Dir, "buildpack.toml"))
+		}
+		all = append(all, data)
 	}
+	return all, nil
+}
 
-	buildpack.Version = bp.Version
-	tarFile := filepath.Join(dest, fmt.Sprintf("%s.%s.tar", buildpack.EscapedID(), bp.Version))
-	if err := archive.CreateTar(tarFile, dir, filepath.Join("/buildpacks", buildpack.EscapedID(), bp.Version), 0, 0); err != nil {
+// buildpackLayer creates and returns the location of a tgz file for a buildpack layer. That file will reside in the `dest` directory.
+// The tgz file is created from the directory the buildpack was fetched to.
+func (f *BuilderFactory) buildpackLayer(dest string, buildpack buildpack.Buildpack, data *BuildpackData) (layerTar string, err error) {
+	tarFile := filepath.Join(dest, fmt.Sprintf("%s.%s.tar", buildpack.EscapedID(), data.BP.Version))
+	if err := archive.CreateNormalizedTar(tarFile, buildpack.Dir, filepath.Join("/buildpacks", buildpack.EscapedID(), data.BP.Version), 0, 0); err != nil {
 		return "", err
 	}
-	return tarFile, err
+	return tarFile, nil
 }
 
 func (f *BuilderFactory) buildpackData(buildpack buildpack.Buildpack, dir string) (*BuildpackData, error) {
@@ -291,12 +631,83 @@ func (f *BuilderFactory) latestLayer(buildpacks []buildpack.Buildpack, dest, bui
 		}
 	}
 	tarFile := filepath.Join(dest, fmt.Sprintf("%s.%s.tar", "latest", "buildpacks"))
-	if err := archive.CreateTar(tarFile, layerDir, "/buildpacks", 0, 0); err != nil {
+	if err := archive.CreateNormalizedTar(tarFile, layerDir, "/buildpacks", 0, 0); err != nil {
 		return "", err
 	}
 	return tarFile, nil
 }
 
+func validateBuildpackStacks(stackID string, buildpacks []*BuildpackData) error {
+	var incompatible []string
+	for _, data := range buildpacks {
+		if data.supportsStack(stackID) {
+			continue
+		}
+
+		supported := "no stacks"
+		if ids := data.stackIDs(); len(ids) > 0 {
+			var symbols []string
+			for _, id := range ids {
+				symbols = append(symbols, style.Symbol(id))
+			}
+			supported = strings.Join(symbols, ", ")
+		}
+		incompatible = append(incompatible, fmt.Sprintf("  %s supports %s", style.Symbol(data.BP.ID+"@"+data.BP.Version), supported))
+	}
+
+	if len(incompatible) > 0 {
+		return fmt.Errorf("buildpacks are not compatible with stack %s:\n%s", style.Symbol(stackID), strings.Join(incompatible, "\n"))
+	}
+	return nil
+}
+
+// validateGroups resolves each buildpack in the detection order groups against the buildpacks included in the
+// builder, where a version of `latest` refers to the buildpack marked as latest. It returns the buildpacks that no
+// group uses.
+func validateGroups(groups []lifecycle.BuildpackGroup, buildpacks []buildpack.Buildpack, buildpacksData []*BuildpackData) (unused []string, err error) {
+	used := make([]bool, len(buildpacks))
+	var dangling []string
+	for i, group := range groups {
+		for _, groupBP := range group.Buildpacks {
+			var (
+				found     bool
+				available []string
+			)
+			for j, bp := range buildpacks {
+				if bp.ID != groupBP.ID {
+					continue
+				}
+				version := buildpacksData[j].BP.Version
+				available = append(available, style.Symbol(bp.ID+"@"+version))
+				if groupBP.Version == version || (groupBP.Version == "latest" && bp.Latest) {
+					found = true
+					used[j] = true
+				}
+			}
+			if found {
+				continue
+			}
+
+			msg := fmt.Sprintf("  group #%d: %s", i+1, style.Symbol(groupBP.ID+"@"+groupBP.Version))
+			if len(available) > 0 {
+				msg += fmt.Sprintf(" (builder has %s)", strings.Join(available, ", "))
+			}
+			dangling = append(dangling, msg)
+		}
+	}
+
+	if len(dangling) > 0 {
+		return nil, fmt.Errorf("groups reference buildpacks that are not in the builder:\n%s", strings.Join(dangling, "\n"))
+	}
+
+	for i, bp := range buildpacks {
+		if !used[i] {
+			unused = append(unused, bp.ID+"@"+buildpacksData[i].BP.Version)
+		}
+	}
+	return unused, nil
+}
+
 func validateBuilderTOML(builderTOML *builder.TOML) error {
 	if builderTOML == nil {
 		return errors.New("builder toml is empty")
@@ -1,23 +1,33 @@
 package pack_test
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"github.com/fatih/color"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/buildpack/lifecycle"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/builder"
 	"github.com/buildpack/pack/buildpack"
 	"github.com/buildpack/pack/config"
 	"github.com/buildpack/pack/logging"
@@ -39,6 +49,7 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 		var (
 			mockController *gomock.Controller
 			mockFetcher    *mocks.MockFetcher
+			mockDocker     *mocks.MockDocker
 			factory        pack.BuilderFactory
 			outBuf         bytes.Buffer
 			errBuf         bytes.Buffer
@@ -47,6 +58,7 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 		it.Before(func() {
 			mockController = gomock.NewController(t)
 			mockFetcher = mocks.NewMockFetcher(mockController)
+			mockDocker = mocks.NewMockDocker(mockController)
 
 			packHome, err := ioutil.TempDir("", ".pack")
 			if err != nil {
@@ -65,7 +77,8 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 				Logger:           logger,
 				Config:           cfg,
 				Fetcher:          mockFetcher,
-				BuildpackFetcher: buildpack.NewFetcher(logger, cfg.Path()),
+				BuildpackFetcher: buildpack.NewFetcher(logger, mockFetcher, cfg.Path()),
+				Docker:           mockDocker,
 			}
 		})
 
@@ -78,7 +91,9 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 				mockBaseImage := mocks.NewMockImage(mockController)
 
 				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/build", gomock.Any()).Return(mockBaseImage, nil)
+				mockBaseImage.EXPECT().Digest().Return("sha256:some-digest", nil)
 				mockBaseImage.EXPECT().Rename("some/image")
+				mockFetcher.EXPECT().FetchLocalImage("some/image").Return(nil, fmt.Errorf("no such image"))
 
 				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
 					RepoName:        "some/image",
@@ -87,18 +102,93 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 				if err != nil {
 					t.Fatalf("error creating builder config: %s", err)
 				}
-				h.AssertSameInstance(t, cfg.Repo, mockBaseImage)
+				h.AssertEq(t, cfg.Repo.Name(), "some/image")
 				checkBuildpacks(t, cfg.Buildpacks)
 				checkGroups(t, cfg.Groups)
 				h.AssertEq(t, cfg.BuilderDir, "testdata")
 				h.AssertEq(t, cfg.RunImage, "some/run")
 				h.AssertEq(t, cfg.RunImageMirrors, []string{"gcr.io/some/run2"})
+				h.AssertNil(t, cfg.Previous)
+			})
+
+			it("reads the description, metadata and provenance of the builder", func() {
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/build", gomock.Any()).Return(mockBaseImage, nil)
+				mockBaseImage.EXPECT().Digest().Return("sha256:some-digest", nil)
+				mockBaseImage.EXPECT().Rename("some/image")
+				mockFetcher.EXPECT().FetchLocalImage("some/image").Return(nil, fmt.Errorf("no such image"))
+
+				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: filepath.Join("testdata", "builder.toml"),
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.Description, "Some builder for some apps")
+				h.AssertEq(t, cfg.Metadata, map[string]interface{}{"maintainer": "some-team@example.com"})
+				h.AssertEq(t, cfg.BuildImage, "some/build")
+				h.AssertEq(t, cfg.BuildImageDigest, "sha256:some-digest")
+				h.AssertEq(t, cfg.Created, archive.NormalizedDateTime)
+			})
+
+			it("records the current time as the creation time when asked to", func() {
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/build", gomock.Any()).Return(mockBaseImage, nil)
+				mockBaseImage.EXPECT().Digest().Return("sha256:some-digest", nil)
+				mockBaseImage.EXPECT().Rename("some/image")
+				mockFetcher.EXPECT().FetchLocalImage("some/image").Return(nil, fmt.Errorf("no such image"))
+
+				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: filepath.Join("testdata", "builder.toml"),
+					UseCurrentTime:  true,
+				})
+				h.AssertNil(t, err)
+				if time.Since(cfg.Created) > time.Minute {
+					t.Fatalf("expected creation time to be now, got %s", cfg.Created)
+				}
+			})
+
+			it("reads the metadata of an existing builder with the same name", func() {
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockPreviousImage := mocks.NewMockImage(mockController)
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/build", gomock.Any()).Return(mockBaseImage, nil)
+				mockBaseImage.EXPECT().Digest().Return("sha256:some-digest", nil)
+				mockBaseImage.EXPECT().Rename("some/image")
+				mockFetcher.EXPECT().FetchLocalImage("some/image").Return(mockPreviousImage, nil)
+				mockPreviousImage.EXPECT().Found().Return(true, nil)
+				mockPreviousImage.EXPECT().Label("io.buildpacks.builder.metadata").
+					Return(`{"buildpacks":[{"id":"some.bp1","version":"1.2.3","latest":false,"layerDiffID":"sha256:abc"}]}`, nil)
+
+				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: filepath.Join("testdata", "builder.toml"),
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.Previous.Buildpacks, []builder.BuildpackMetadata{
+					{ID: "some.bp1", Version: "1.2.3", LayerDiffID: "sha256:abc"},
+				})
+			})
+
+			it("doesn't fetch the base image when --dry-run flag is provided", func() {
+				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: filepath.Join("testdata", "builder.toml"),
+					DryRun:          true,
+				})
+				h.AssertNil(t, err)
+				h.AssertNil(t, cfg.Repo)
+				h.AssertEq(t, cfg.DryRun, true)
+				checkGroups(t, cfg.Groups)
 			})
 
 			it("doesn't pull a new base image when --no-pull flag is provided", func() {
 				mockBaseImage := mocks.NewMockImage(mockController)
 				mockFetcher.EXPECT().FetchLocalImage("some/build").Return(mockBaseImage, nil)
+				mockBaseImage.EXPECT().Digest().Return("sha256:some-digest", nil)
 				mockBaseImage.EXPECT().Rename("some/image")
+				mockPreviousImage := mocks.NewMockImage(mockController)
+				mockFetcher.EXPECT().FetchLocalImage("some/image").Return(mockPreviousImage, nil)
+				mockPreviousImage.EXPECT().Found().Return(false, nil)
 
 				config, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
 					RepoName:        "some/image",
@@ -108,7 +198,7 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 				if err != nil {
 					t.Fatalf("error creating builder config: %s", err)
 				}
-				h.AssertSameInstance(t, config.Repo, mockBaseImage)
+				h.AssertEq(t, config.Repo.Name(), "some/image")
 				checkBuildpacks(t, config.Buildpacks)
 				checkGroups(t, config.Groups)
 				h.AssertEq(t, config.BuilderDir, "testdata")
@@ -130,7 +220,11 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 				it("uses a registry store and doesn't pull base image", func() {
 					mockBaseImage := mocks.NewMockImage(mockController)
 					mockFetcher.EXPECT().FetchRemoteImage("some/build").Return(mockBaseImage, nil)
+					mockBaseImage.EXPECT().Digest().Return("sha256:some-digest", nil)
 					mockBaseImage.EXPECT().Rename("some/image")
+					mockPreviousImage := mocks.NewMockImage(mockController)
+					mockFetcher.EXPECT().FetchRemoteImage("some/image").Return(mockPreviousImage, nil)
+					mockPreviousImage.EXPECT().Found().Return(false, nil)
 
 					config, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
 						RepoName:        "some/image",
@@ -140,13 +234,97 @@ func testBuilderFactory(t *testing.T, when spec.G, it spec.S) {
 					if err != nil {
 						t.Fatalf("error creating builder config: %s", err)
 					}
-					h.AssertSameInstance(t, config.Repo, mockBaseImage)
+					h.AssertEq(t, config.Repo.Name(), "some/image")
 					checkBuildpacks(t, config.Buildpacks)
 					checkGroups(t, config.Groups)
 					h.AssertEq(t, config.BuilderDir, "testdata")
 				})
 			})
 
+			it("fetches the lifecycle selected in builder.toml", func() {
+				file, err := ioutil.TempFile("", "builder.toml")
+				h.AssertNil(t, err)
+				defer os.Remove(file.Name())
+
+				lifecycleDir, err := filepath.Abs(filepath.Join("testdata", "lifecycle"))
+				h.AssertNil(t, err)
+				_, err = file.WriteString(fmt.Sprintf(`
+[stack]
+id = "some.id"
+build-image = "packs/build:v3alpha2"
+run-image = "packs/run:v3alpha2"
+
+[lifecycle]
+version = "0.2.0"
+uri = %q
+`, lifecycleDir))
+				h.AssertNil(t, err)
+				file.Close()
+
+				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: file.Name(),
+					DryRun:          true,
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.LifecycleDir, lifecycleDir)
+				h.AssertEq(t, cfg.LifecycleVersion, "0.2.0")
+			})
+
+			when("a lifecycle uri is given without a version", func() {
+				var builderTOML string
+
+				it.Before(func() {
+					file, err := ioutil.TempFile("", "builder.toml")
+					h.AssertNil(t, err)
+					builderTOML = file.Name()
+					file.Close()
+				})
+
+				it.After(func() {
+					os.Remove(builderTOML)
+				})
+
+				writeBuilderTOML := func(lifecycleDir string) {
+					h.AssertNil(t, ioutil.WriteFile(builderTOML, []byte(fmt.Sprintf(`
+[stack]
+id = "some.id"
+build-image = "packs/build:v3alpha2"
+run-image = "packs/run:v3alpha2"
+
+[lifecycle]
+uri = %q
+`, lifecycleDir)), 0644))
+				}
+
+				it("reads the version from the lifecycle.toml of the lifecycle", func() {
+					lifecycleDir, err := filepath.Abs(filepath.Join("testdata", "lifecycle"))
+					h.AssertNil(t, err)
+					writeBuilderTOML(lifecycleDir)
+
+					cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+						RepoName:        "some/image",
+						BuilderTomlPath: builderTOML,
+						DryRun:          true,
+					})
+					h.AssertNil(t, err)
+					h.AssertEq(t, cfg.LifecycleVersion, "0.2.0")
+				})
+
+				it("fails when the lifecycle has no lifecycle.toml", func() {
+					lifecycleDir, err := filepath.Abs(filepath.Join("testdata", "buildpack"))
+					h.AssertNil(t, err)
+					writeBuilderTOML(lifecycleDir)
+
+					_, err = factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+						RepoName:        "some/image",
+						BuilderTomlPath: builderTOML,
+						DryRun:          true,
+					})
+					h.AssertError(t, err, "could not determine the version of the lifecycle in")
+				})
+			})
+
 			it("validates the presence of the id field", func() {
 				file, err := ioutil.TempFile("", "builder.toml")
 				h.AssertNil(t, err)
@@ -236,13 +414,18 @@ build-image = "packs/build:v3alpha2"
 				mockImage.EXPECT().SetEnv(gomock.Any(), gomock.Any()).Do(func(key, val string) { env[key] = val }).AnyTimes()
 				mockImage.EXPECT().Save()
 
+				factory.PackVersion = "1.2.3"
 				builderConfig = pack.BuilderConfig{
-					Repo:            mockImage,
-					Buildpacks:      []buildpack.Buildpack{},
-					Groups:          []lifecycle.BuildpackGroup{},
-					BuilderDir:      "",
-					RunImage:        "myorg/run",
-					RunImageMirrors: []string{"gcr.io/myorg/run"},
+					Repo:             mockImage,
+					Buildpacks:       []buildpack.Buildpack{},
+					Groups:           []lifecycle.BuildpackGroup{},
+					BuilderDir:       "",
+					StackID:          "some.stack.id",
+					RunImage:         "myorg/run",
+					RunImageMirrors:  []string{"gcr.io/myorg/run"},
+					BuildImage:       "myorg/build",
+					BuildImageDigest: "sha256:some-digest",
+					Created:          time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC),
 				}
 			})
 
@@ -250,10 +433,18 @@ build-image = "packs/build:v3alpha2"
 				h.AssertNil(t, factory.Create(builderConfig))
 				h.AssertEq(t,
 					labels["io.buildpacks.builder.metadata"],
-					`{"buildpacks":[],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}}}`,
+					`{"buildpacks":[],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}},"createdBy":{"packVersion":"1.2.3","created":"2019-04-01T12:00:00Z","buildImage":"myorg/build","buildImageDigest":"sha256:some-digest"}}`,
 				)
 			})
 
+			it("stores the description and metadata in the builder label", func() {
+				builderConfig.Description = "Some builder for some apps"
+				builderConfig.Metadata = map[string]interface{}{"maintainer": "some-team@example.com"}
+				h.AssertNil(t, factory.Create(builderConfig))
+				h.AssertContains(t, labels["io.buildpacks.builder.metadata"], `{"description":"Some builder for some apps",`)
+				h.AssertContains(t, labels["io.buildpacks.builder.metadata"], `"metadata":{"maintainer":"some-team@example.com"}}`)
+			})
+
 			it("writes a stack.toml file", func() {
 				h.AssertNil(t, factory.Create(builderConfig))
 
@@ -264,6 +455,51 @@ build-image = "packs/build:v3alpha2"
 				h.AssertContains(t, content.String(), `mirrors = ["gcr.io/myorg/run"]`)
 			})
 
+			it("writes only stack.toml to the stack layer", func() {
+				h.AssertNil(t, factory.Create(builderConfig))
+
+				tr := tar.NewReader(savedLayers["stack.tar"])
+				var names []string
+				for {
+					header, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					h.AssertNil(t, err)
+					names = append(names, header.Name)
+				}
+				h.AssertEq(t, names, []string{"/buildpacks", "/buildpacks/stack.toml"})
+			})
+
+			it("writes the same layers when run again with the same inputs", func() {
+				builderConfig.Buildpacks = []buildpack.Buildpack{
+					{ID: "some-buildpack-id", Dir: "testdata/buildpack", Latest: true},
+				}
+				builderConfig.Groups = []lifecycle.BuildpackGroup{
+					{Buildpacks: []*lifecycle.Buildpack{{ID: "some-buildpack-id", Version: "latest"}}},
+				}
+				h.AssertNil(t, factory.Create(builderConfig))
+
+				secondLayers := make(map[string]*bytes.Buffer)
+				secondImage := mocks.NewMockImage(mockController)
+				secondImage.EXPECT().AddLayer(gomock.Any()).Do(func(layerPath string) {
+					buf, err := ioutil.ReadFile(layerPath)
+					h.AssertNil(t, err)
+					secondLayers[filepath.Base(layerPath)] = bytes.NewBuffer(buf)
+				}).AnyTimes()
+				secondImage.EXPECT().SetLabel("io.buildpacks.builder.metadata", labels["io.buildpacks.builder.metadata"])
+				secondImage.EXPECT().SetEnv(gomock.Any(), gomock.Any()).AnyTimes()
+				secondImage.EXPECT().Save()
+
+				builderConfig.Repo = secondImage
+				h.AssertNil(t, factory.Create(builderConfig))
+
+				h.AssertEq(t, len(secondLayers), len(savedLayers))
+				for name, layer := range savedLayers {
+					h.AssertEq(t, secondLayers[name].String(), layer.String())
+				}
+			})
+
 			it("writes the stack.toml file path to an env var", func() {
 				h.AssertNil(t, factory.Create(builderConfig))
 				content, exists := env["CNB_STACK_PATH"]
@@ -271,6 +507,35 @@ build-image = "packs/build:v3alpha2"
 				h.AssertContains(t, content, "/buildpacks/stack.toml")
 			})
 
+			it("doesn't record a lifecycle when none is selected", func() {
+				h.AssertNil(t, factory.Create(builderConfig))
+				_, exists := savedLayers["lifecycle.tar"]
+				h.AssertEq(t, exists, false)
+				h.AssertNotContains(t, labels["io.buildpacks.builder.metadata"], `"lifecycle"`)
+			})
+
+			when("builder config contains a lifecycle", func() {
+				it.Before(func() {
+					builderConfig.LifecycleDir = filepath.Join("testdata", "lifecycle")
+					builderConfig.LifecycleVersion = "0.2.0"
+				})
+
+				it("adds the lifecycle binaries as a layer", func() {
+					h.AssertNil(t, factory.Create(builderConfig))
+					buf, exists := savedLayers["lifecycle.tar"]
+					h.AssertEq(t, exists, true)
+
+					contents, err := h.UntarSingleFile(buf, "/lifecycle/detector")
+					h.AssertNil(t, err)
+					h.AssertContains(t, string(contents), `echo "detector"`)
+				})
+
+				it("stores the lifecycle version in the builder label", func() {
+					h.AssertNil(t, factory.Create(builderConfig))
+					h.AssertContains(t, labels["io.buildpacks.builder.metadata"], `"lifecycle":{"version":"0.2.0"}`)
+				})
+			})
+
 			when("builder config contains buildpacks", func() {
 				it.Before(func() {
 					builderConfig.Buildpacks = []buildpack.Buildpack{
@@ -280,16 +545,87 @@ build-image = "packs/build:v3alpha2"
 
 				it("stores metadata about the buildpacks in the builder label", func() {
 					h.AssertNil(t, factory.Create(builderConfig))
+					diffID := fmt.Sprintf("sha256:%x", sha256.Sum256(savedLayers["some-buildpack-id.some-buildpack-version.tar"].Bytes()))
 					h.AssertEq(t,
 						labels["io.buildpacks.builder.metadata"],
-						`{"buildpacks":[{"id":"some-buildpack-id","version":"some-buildpack-version","latest":true}],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}}}`,
+						`{"buildpacks":[{"id":"some-buildpack-id","version":"some-buildpack-version","latest":true,"name":"Some Buildpack","description":"Provides some dependency","homepage":"https://example.com/some-buildpack","stacks":["some.stack.id","other.stack.id"],"layerDiffID":"`+diffID+`"}],"groups":[],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}},"createdBy":{"packVersion":"1.2.3","created":"2019-04-01T12:00:00Z","buildImage":"myorg/build","buildImageDigest":"sha256:some-digest"}}`,
 					)
 				})
+
+				when("a previous builder contains the same buildpack layer", func() {
+					var secondImage *mocks.MockImage
+
+					it.Before(func() {
+						h.AssertNil(t, factory.Create(builderConfig))
+						var previous builder.Metadata
+						h.AssertNil(t, json.Unmarshal([]byte(labels["io.buildpacks.builder.metadata"]), &previous))
+						builderConfig.Previous = &previous
+
+						secondImage = mocks.NewMockImage(mockController)
+						secondImage.EXPECT().SetLabel(gomock.Any(), gomock.Any())
+						secondImage.EXPECT().SetEnv(gomock.Any(), gomock.Any()).AnyTimes()
+						secondImage.EXPECT().Save()
+						builderConfig.Repo = secondImage
+					})
+
+					it("reuses the layer instead of adding it again", func() {
+						var added []string
+						secondImage.EXPECT().AddLayer(gomock.Any()).Do(func(layerPath string) {
+							added = append(added, filepath.Base(layerPath))
+						}).AnyTimes()
+						secondImage.EXPECT().ReuseLayer(builderConfig.Previous.Buildpacks[0].LayerDiffID)
+
+						h.AssertNil(t, factory.Create(builderConfig))
+						h.AssertEq(t, added, []string{"order.tar", "latest.buildpacks.tar", "stack.tar"})
+					})
+
+					it("adds the layer when the buildpack has changed", func() {
+						builderConfig.Previous.Buildpacks[0].LayerDiffID = "sha256:some-other-diff-id"
+						secondImage.EXPECT().AddLayer(gomock.Any()).Times(4)
+
+						h.AssertNil(t, factory.Create(builderConfig))
+					})
+
+					it("reuses the layer without building it when the buildpack comes from the same source", func() {
+						builderConfig.Buildpacks[0].SourceDigest = "sha256:some-source-digest"
+						builderConfig.Previous.Buildpacks[0].SourceDigest = "sha256:some-source-digest"
+						builderConfig.Previous.Buildpacks[0].LayerDiffID = "sha256:some-other-diff-id"
+						var added []string
+						secondImage.EXPECT().AddLayer(gomock.Any()).Do(func(layerPath string) {
+							added = append(added, filepath.Base(layerPath))
+						}).AnyTimes()
+						secondImage.EXPECT().ReuseLayer("sha256:some-other-diff-id")
+
+						h.AssertNil(t, factory.Create(builderConfig))
+						h.AssertEq(t, added, []string{"order.tar", "latest.buildpacks.tar", "stack.tar"})
+					})
+				})
+			})
+
+			when("builder config contains a buildpack for any stack", func() {
+				it.Before(func() {
+					builderConfig.StackID = "some.other.stack.id"
+					builderConfig.Buildpacks = []buildpack.Buildpack{
+						{ID: "any-stack-buildpack-id", Dir: "testdata/buildpack-any-stack"},
+					}
+				})
+
+				it("adds the buildpack", func() {
+					h.AssertNil(t, factory.Create(builderConfig))
+					_, exists := savedLayers["any-stack-buildpack-id.any-stack-buildpack-version.tar"]
+					h.AssertEq(t, exists, true)
+				})
 			})
 
 			when("builder config contains groups", func() {
 				it.Before(func() {
-					builderConfig.Groups = []lifecycle.BuildpackGroup{{Buildpacks: []*lifecycle.Buildpack{{ID: "bpId", Version: "bpVersion"}}}}
+					builderConfig.Buildpacks = []buildpack.Buildpack{
+						{ID: "some-buildpack-id", Dir: "testdata/buildpack"},
+						{ID: "any-stack-buildpack-id", Dir: "testdata/buildpack-any-stack", Latest: true},
+					}
+					builderConfig.Groups = []lifecycle.BuildpackGroup{
+						{Buildpacks: []*lifecycle.Buildpack{{ID: "some-buildpack-id", Version: "some-buildpack-version"}}},
+					}
 				})
 
 				it("should write a 'order.toml' that lists buildpack groups", func() {
@@ -299,16 +635,218 @@ build-image = "packs/build:v3alpha2"
 
 					contents, err := h.UntarSingleFile(buf, "/buildpacks/order.toml")
 					h.AssertNil(t, err)
-					h.AssertContains(t, string(contents), `id = "bpId"`)
+					h.AssertContains(t, string(contents), `id = "some-buildpack-id"`)
 				})
 
 				it("stores metadata about the groups in the builder label", func() {
 					h.AssertNil(t, factory.Create(builderConfig))
-					h.AssertEq(t,
+					h.AssertContains(t,
+						labels["io.buildpacks.builder.metadata"],
+						`"groups":[{"buildpacks":[{"id":"some-buildpack-id","version":"some-buildpack-version","latest":false}]}]`,
+					)
+				})
+
+				it("keeps optional buildpacks optional", func() {
+					builderConfig.Groups[0].Buildpacks = append(builderConfig.Groups[0].Buildpacks,
+						&lifecycle.Buildpack{ID: "any-stack-buildpack-id", Version: "latest", Optional: true})
+					h.AssertNil(t, factory.Create(builderConfig))
+
+					contents, err := h.UntarSingleFile(savedLayers["order.tar"], "/buildpacks/order.toml")
+					h.AssertNil(t, err)
+					h.AssertContains(t, string(contents), `optional = true`)
+					h.AssertContains(t,
 						labels["io.buildpacks.builder.metadata"],
-						`{"buildpacks":[],"groups":[{"buildpacks":[{"id":"bpId","version":"bpVersion","latest":false}]}],"stack":{"runImage":{"image":"myorg/run","mirrors":["gcr.io/myorg/run"]}}}`,
+						`{"id":"any-stack-buildpack-id","version":"latest","latest":false,"optional":true}`,
 					)
 				})
+
+				it("warns about buildpacks that no group uses", func() {
+					h.AssertNil(t, factory.Create(builderConfig))
+					h.AssertContains(t, outBuf.String(), "Warning: buildpack 'any-stack-buildpack-id@any-stack-buildpack-version' is not used by any group")
+					h.AssertNotContains(t, outBuf.String(), "'some-buildpack-id@some-buildpack-version' is not used")
+				})
+
+				it("resolves 'latest' to the buildpack marked as latest", func() {
+					builderConfig.Groups = append(builderConfig.Groups, lifecycle.BuildpackGroup{
+						Buildpacks: []*lifecycle.Buildpack{{ID: "any-stack-buildpack-id", Version: "latest"}},
+					})
+					h.AssertNil(t, factory.Create(builderConfig))
+					h.AssertNotContains(t, outBuf.String(), "is not used by any group")
+				})
+			})
+		})
+
+		when("#Create saves a builder read from flags", func() {
+			type imageManifest struct {
+				Config   string
+				RepoTags []string
+				Layers   []string
+			}
+
+			var builderTomlPath string
+
+			it.Before(func() {
+				file, err := ioutil.TempFile("", "builder.toml")
+				h.AssertNil(t, err)
+				builderTomlPath = file.Name()
+
+				buildpackDir, err := filepath.Abs(filepath.Join("testdata", "buildpack"))
+				h.AssertNil(t, err)
+				_, err = file.WriteString(fmt.Sprintf(`
+[[buildpacks]]
+id = "some-buildpack-id"
+uri = %q
+
+[[groups]]
+buildpacks = [{ id = "some-buildpack-id", version = "some-buildpack-version" }]
+
+[stack]
+id = "some.stack.id"
+build-image = "some/build"
+run-image = "some/run"
+`, buildpackDir))
+				h.AssertNil(t, err)
+				file.Close()
+			})
+
+			it.After(func() {
+				os.Remove(builderTomlPath)
+			})
+
+			createBuilder := func() (imageManifest, string) {
+				t.Helper()
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/build", gomock.Any()).Return(mockBaseImage, nil)
+				mockBaseImage.EXPECT().Digest().Return("sha256:some-digest", nil)
+				mockBaseImage.EXPECT().Rename("some/image")
+				mockBaseImage.EXPECT().SetLabel(gomock.Any(), gomock.Any()).AnyTimes()
+				mockBaseImage.EXPECT().SetEnv(gomock.Any(), gomock.Any()).AnyTimes()
+				mockFetcher.EXPECT().FetchLocalImage("some/image").Return(nil, fmt.Errorf("no such image"))
+
+				cfg, err := factory.BuilderConfigFromFlags(context.TODO(), pack.CreateBuilderFlags{
+					RepoName:        "some/image",
+					BuilderTomlPath: builderTomlPath,
+				})
+				h.AssertNil(t, err)
+
+				var loaded []byte
+				mockDocker.EXPECT().ImageInspectWithRaw(gomock.Any(), "some/build").Return(dockertypes.ImageInspect{
+					Config: &container.Config{Env: []string{"PATH=/usr/bin"}},
+					RootFS: dockertypes.RootFS{Layers: []string{"sha256:some-base-layer"}},
+				}, nil, nil)
+				mockDocker.EXPECT().ImageInspectWithRaw(gomock.Any(), "some/image").Return(dockertypes.ImageInspect{}, nil, nil)
+				mockDocker.EXPECT().ImageLoad(gomock.Any(), gomock.Any(), true).
+					DoAndReturn(func(_ context.Context, input io.Reader, _ bool) (dockertypes.ImageLoadResponse, error) {
+						loaded, err = ioutil.ReadAll(input)
+						h.AssertNil(t, err)
+						return dockertypes.ImageLoadResponse{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+					})
+				h.AssertNil(t, factory.Create(cfg))
+
+				contents, err := h.UntarSingleFile(bytes.NewReader(loaded), "manifest.json")
+				h.AssertNil(t, err)
+				var manifest []imageManifest
+				h.AssertNil(t, json.Unmarshal(contents, &manifest))
+				h.AssertEq(t, len(manifest), 1)
+				imgConfig, err := h.UntarSingleFile(bytes.NewReader(loaded), manifest[0].Config)
+				h.AssertNil(t, err)
+				return manifest[0], string(imgConfig)
+			}
+
+			it("loads the builder into the daemon once, on top of the layers of the base image", func() {
+				manifest, imgConfig := createBuilder()
+				h.AssertEq(t, manifest.RepoTags, []string{"index.docker.io/some/image:latest"})
+				h.AssertEq(t, manifest.Layers[0], "")
+				for _, layer := range manifest.Layers[1:] {
+					h.AssertNotEq(t, layer, "")
+				}
+				h.AssertContains(t, imgConfig, `"diff_ids":["sha256:some-base-layer",`)
+				h.AssertContains(t, imgConfig, `"Env":["PATH=/usr/bin","CNB_STACK_PATH=/buildpacks/stack.toml"]`)
+				h.AssertContains(t, imgConfig, `"io.buildpacks.builder.metadata":`)
+			})
+
+			it("records a fixed creation time, so the same builder is the same image", func() {
+				first, imgConfig := createBuilder()
+				h.AssertContains(t, imgConfig, `"created":"1980-01-01T00:00:01Z"`)
+				second, _ := createBuilder()
+				h.AssertEq(t, second.Config, first.Config)
+			})
+		})
+
+		when("#Create is a dry run", func() {
+			it("prints the detection order without building any layer", func() {
+				h.AssertNil(t, factory.Create(pack.BuilderConfig{
+					Repo:    mocks.NewMockImage(mockController),
+					StackID: "some.stack.id",
+					Buildpacks: []buildpack.Buildpack{
+						{ID: "some-buildpack-id", Dir: "testdata/buildpack"},
+						{ID: "any-stack-buildpack-id", Dir: "testdata/buildpack-any-stack", Latest: true},
+					},
+					Groups: []lifecycle.BuildpackGroup{
+						{Buildpacks: []*lifecycle.Buildpack{
+							{ID: "some-buildpack-id", Version: "some-buildpack-version"},
+							{ID: "any-stack-buildpack-id", Version: "latest", Optional: true},
+						}},
+					},
+					DryRun: true,
+				}))
+				h.AssertContains(t, outBuf.String(), `Detection Order:
+  Group #1:
+    some-buildpack-id@some-buildpack-version
+    any-stack-buildpack-id@latest (optional)
+`)
+			})
+		})
+
+		when("#Create is given an invalid builder", func() {
+			var builderConfig pack.BuilderConfig
+
+			it.Before(func() {
+				builderConfig = pack.BuilderConfig{
+					Repo:    mocks.NewMockImage(mockController),
+					StackID: "some.unsupported.stack.id",
+					Buildpacks: []buildpack.Buildpack{
+						{ID: "some-buildpack-id", Dir: "testdata/buildpack"},
+						{ID: "any-stack-buildpack-id", Dir: "testdata/buildpack-any-stack"},
+					},
+					RunImage: "myorg/run",
+				}
+			})
+
+			it("fails before building any layer when the lifecycle is missing binaries", func() {
+				builderConfig.StackID = "some.stack.id"
+				builderConfig.Buildpacks = nil
+				builderConfig.LifecycleDir = filepath.Join("testdata", "buildpack")
+
+				err := factory.Create(builderConfig)
+				h.AssertError(t, err, "lifecycle in 'testdata/buildpack' is missing 'detector', 'restorer', 'analyzer', 'builder', 'exporter', 'cacher'")
+			})
+
+			it("fails before building any layer, listing the stacks each buildpack supports", func() {
+				err := factory.Create(builderConfig)
+				h.AssertError(t, err, `buildpacks are not compatible with stack 'some.unsupported.stack.id':
+  'some-buildpack-id@some-buildpack-version' supports 'some.stack.id', 'other.stack.id'`)
+				h.AssertNotContains(t, err.Error(), "any-stack-buildpack-id")
+			})
+
+			it("fails before building any layer, listing every group entry that is not in the builder", func() {
+				builderConfig.StackID = "some.stack.id"
+				builderConfig.Groups = []lifecycle.BuildpackGroup{
+					{Buildpacks: []*lifecycle.Buildpack{
+						{ID: "some-buildpack-id", Version: "some-buildpack-version"},
+						{ID: "some-buildpack-id", Version: "0.0.1"},
+					}},
+					{Buildpacks: []*lifecycle.Buildpack{
+						{ID: "missing-buildpack-id", Version: "1.0.0"},
+						{ID: "any-stack-buildpack-id", Version: "latest"},
+					}},
+				}
+
+				err := factory.Create(builderConfig)
+				h.AssertError(t, err, `groups reference buildpacks that are not in the builder:
+  group #1: 'some-buildpack-id@0.0.1' (builder has 'some-buildpack-id@some-buildpack-version')
+  group #2: 'missing-buildpack-id@1.0.0'
+  group #2: 'any-stack-buildpack-id@latest' (builder has 'any-stack-buildpack-id@any-stack-buildpack-version')`)
 			})
 		})
 	})
@@ -323,8 +861,9 @@ func checkGroups(t *testing.T, groups []lifecycle.BuildpackGroup) {
 				Version: "1.2.3",
 			},
 			{
-				ID:      "some/bp2",
-				Version: "1.2.4",
+				ID:       "some/bp2",
+				Version:  "1.2.4",
+				Optional: true,
 			},
 		}},
 		{Buildpacks: []*lifecycle.Buildpack{
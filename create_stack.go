@@ -0,0 +1,170 @@
+package pack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/buildpack/lifecycle/image"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/stack"
+	"github.com/buildpack/pack/style"
+)
+
+type CreateStackFlags struct {
+	BaseImage  string
+	StackID    string
+	BuildImage string
+	RunImage   string
+	UserID     int
+	GroupID    int
+	NoPull     bool
+}
+
+type CreateStackConfig struct {
+	BaseImage  string
+	StackID    string
+	BuildImage string
+	RunImage   string
+	UserID     int
+	GroupID    int
+	Pull       bool
+}
+
+type StackFactory struct {
+	Logger  *logging.Logger
+	Docker  Docker
+	Fetcher Fetcher
+}
+
+func (f *StackFactory) CreateStackConfigFromFlags(flags CreateStackFlags) (CreateStackConfig, error) {
+	if flags.BaseImage == "" {
+		return CreateStackConfig{}, errors.New("base image must be specified")
+	}
+	if flags.StackID == "" {
+		return CreateStackConfig{}, errors.New("stack id must be specified")
+	}
+	if flags.UserID <= 0 || flags.GroupID <= 0 {
+		return CreateStackConfig{}, fmt.Errorf("user and group ids must be greater than 0, got %d:%d", flags.UserID, flags.GroupID)
+	}
+
+	config := CreateStackConfig{
+		BaseImage:  flags.BaseImage,
+		StackID:    flags.StackID,
+		BuildImage: flags.BuildImage,
+		RunImage:   flags.RunImage,
+		UserID:     flags.UserID,
+		GroupID:    flags.GroupID,
+		Pull:       !flags.NoPull,
+	}
+	if config.BuildImage == "" {
+		config.BuildImage = strings.ToLower(flags.StackID) + "-build"
+	}
+	if config.RunImage == "" {
+		config.RunImage = strings.ToLower(flags.StackID) + "-run"
+	}
+	if config.BuildImage == config.RunImage {
+		return CreateStackConfig{}, fmt.Errorf("build image and run image must have different names, both are %s", style.Symbol(config.BuildImage))
+	}
+	return config, nil
+}
+
+// Create builds the build image and the run image of a stack on top of the same base image. Both images get the
+// stack ID label and a CNB user; the build image also declares that user in CNB_USER_ID and CNB_GROUP_ID, and the
+// run image runs as it.
+func (f *StackFactory) Create(ctx context.Context, config CreateStackConfig) error {
+	f.Logger.Verbose("Creating build image %s", style.Symbol(config.BuildImage))
+	if err := f.buildImage(ctx, config, config.BuildImage, buildImageDockerfile(config)); err != nil {
+		return errors.Wrapf(err, "creating build image %s", style.Symbol(config.BuildImage))
+	}
+
+	f.Logger.Verbose("Creating run image %s", style.Symbol(config.RunImage))
+	if err := f.buildImage(ctx, config, config.RunImage, runImageDockerfile(config)); err != nil {
+		return errors.Wrapf(err, "creating run image %s", style.Symbol(config.RunImage))
+	}
+
+	buildImage, err := f.Fetcher.FetchLocalImage(config.BuildImage)
+	if err != nil {
+		return err
+	}
+	runImage, err := f.Fetcher.FetchLocalImage(config.RunImage)
+	if err != nil {
+		return err
+	}
+	return validateStackImages(config.StackID, buildImage, runImage)
+}
+
+func (f *StackFactory) buildImage(ctx context.Context, config CreateStackConfig, name, dockerfile string) error {
+	buildContext, err := archive.CreateSingleFileTarReader("Dockerfile", dockerfile)
+	if err != nil {
+		return err
+	}
+
+	res, err := f.Docker.ImageBuild(ctx, buildContext, dockertypes.ImageBuildOptions{
+		Tags:        []string{name},
+		PullParent:  config.Pull,
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return jsonmessage.DisplayJSONMessagesStream(res.Body, f.Logger.VerboseWriter(), 0, false, nil)
+}
+
+func buildImageDockerfile(config CreateStackConfig) string {
+	return stackDockerfile(config) + fmt.Sprintf(`ENV CNB_USER_ID=%d CNB_GROUP_ID=%d
+`, config.UserID, config.GroupID)
+}
+
+func runImageDockerfile(config CreateStackConfig) string {
+	return stackDockerfile(config) + fmt.Sprintf(`USER %d:%d
+`, config.UserID, config.GroupID)
+}
+
+// stackDockerfile creates the CNB user with the tools of either Debian-based or Alpine-based images.
+func stackDockerfile(config CreateStackConfig) string {
+	return fmt.Sprintf(`FROM %[1]s
+RUN (groupadd cnb --gid %[3]d && useradd --uid %[2]d --gid %[3]d -m -s /bin/sh cnb) || \
+    (addgroup -g %[3]d cnb && adduser -u %[2]d -G cnb -D -s /bin/sh cnb)
+LABEL %[4]s=%[5]q
+`, config.BaseImage, config.UserID, config.GroupID, stack.IDLabel, config.StackID)
+}
+
+// validateStackImages ensures the build image and the run image belong to stackID, and that the build image declares
+// the user buildpacks run as.
+func validateStackImages(stackID string, buildImage, runImage image.Image) error {
+	buildStackID, err := buildImage.Label(stack.IDLabel)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find stack label for build image %s", style.Symbol(buildImage.Name()))
+	}
+	runStackID, err := runImage.Label(stack.IDLabel)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find stack label for run image %s", style.Symbol(runImage.Name()))
+	}
+	if buildStackID != runStackID {
+		return fmt.Errorf("build image %s has stack %s but run image %s has stack %s",
+			style.Symbol(buildImage.Name()), style.Symbol(buildStackID), style.Symbol(runImage.Name()), style.Symbol(runStackID))
+	}
+	if buildStackID != stackID {
+		return fmt.Errorf("stack images have stack %s, expected %s", style.Symbol(buildStackID), style.Symbol(stackID))
+	}
+
+	for _, name := range []string{"CNB_USER_ID", "CNB_GROUP_ID"} {
+		value, err := buildImage.Env(name)
+		if err != nil {
+			return errors.Wrap(err, "reading build image env variables")
+		}
+		if value == "" {
+			return fmt.Errorf("build image %s does not set %s", style.Symbol(buildImage.Name()), style.Symbol(name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package pack_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestCreateStack(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "create_stack", testCreateStack, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testCreateStack(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockDocker     *mocks.MockDocker
+		mockFetcher    *mocks.MockFetcher
+		factory        pack.StackFactory
+		outBuf         bytes.Buffer
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = mocks.NewMockDocker(mockController)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+		factory = pack.StackFactory{
+			Logger:  logging.NewLogger(&outBuf, &outBuf, true, false),
+			Docker:  mockDocker,
+			Fetcher: mockFetcher,
+		}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#CreateStackConfigFromFlags", func() {
+		it("names the images after the stack by default", func() {
+			config, err := factory.CreateStackConfigFromFlags(pack.CreateStackFlags{
+				BaseImage: "ubuntu:18.04",
+				StackID:   "com.example.Stack",
+				UserID:    1000,
+				GroupID:   1000,
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, config, pack.CreateStackConfig{
+				BaseImage:  "ubuntu:18.04",
+				StackID:    "com.example.Stack",
+				BuildImage: "com.example.stack-build",
+				RunImage:   "com.example.stack-run",
+				UserID:     1000,
+				GroupID:    1000,
+				Pull:       true,
+			})
+		})
+
+		it("fails when the build image and run image have the same name", func() {
+			_, err := factory.CreateStackConfigFromFlags(pack.CreateStackFlags{
+				BaseImage:  "ubuntu:18.04",
+				StackID:    "com.example.stack",
+				BuildImage: "some/stack",
+				RunImage:   "some/stack",
+				UserID:     1000,
+				GroupID:    1000,
+			})
+			h.AssertError(t, err, "build image and run image must have different names, both are 'some/stack'")
+		})
+
+		it("fails when the user id is invalid", func() {
+			_, err := factory.CreateStackConfigFromFlags(pack.CreateStackFlags{
+				BaseImage: "ubuntu:18.04",
+				StackID:   "com.example.stack",
+				GroupID:   1000,
+			})
+			h.AssertError(t, err, "user and group ids must be greater than 0, got 0:1000")
+		})
+	})
+
+	when("#Create", func() {
+		var (
+			config      pack.CreateStackConfig
+			dockerfiles map[string]string
+			buildImage  *imgtest.FakeImage
+			runImage    *imgtest.FakeImage
+		)
+
+		it.Before(func() {
+			config = pack.CreateStackConfig{
+				BaseImage:  "ubuntu:18.04",
+				StackID:    "com.example.stack",
+				BuildImage: "some/build",
+				RunImage:   "some/run",
+				UserID:     1234,
+				GroupID:    5678,
+				Pull:       true,
+			}
+
+			dockerfiles = map[string]string{}
+			mockDocker.EXPECT().ImageBuild(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, buildContext io.Reader, options dockertypes.ImageBuildOptions) (dockertypes.ImageBuildResponse, error) {
+					h.AssertEq(t, options.PullParent, true)
+					dockerfile, err := h.UntarSingleFile(buildContext, "Dockerfile")
+					h.AssertNil(t, err)
+					dockerfiles[options.Tags[0]] = string(dockerfile)
+					return dockertypes.ImageBuildResponse{
+						Body: ioutil.NopCloser(strings.NewReader(`{"stream":"Successfully built"}`)),
+					}, nil
+				}).Times(2)
+
+			buildImage = imgtest.NewFakeImage(t, "some/build", "", "")
+			runImage = imgtest.NewFakeImage(t, "some/run", "", "")
+			mockFetcher.EXPECT().FetchLocalImage("some/build").Return(buildImage, nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/run").Return(runImage, nil)
+		})
+
+		it("builds a build image and a run image for the stack", func() {
+			h.AssertNil(t, buildImage.SetLabel("io.buildpacks.stack.id", "com.example.stack"))
+			h.AssertNil(t, buildImage.SetEnv("CNB_USER_ID", "1234"))
+			h.AssertNil(t, buildImage.SetEnv("CNB_GROUP_ID", "5678"))
+			h.AssertNil(t, runImage.SetLabel("io.buildpacks.stack.id", "com.example.stack"))
+
+			h.AssertNil(t, factory.Create(context.TODO(), config))
+
+			for _, name := range []string{"some/build", "some/run"} {
+				h.AssertContains(t, dockerfiles[name], "FROM ubuntu:18.04\n")
+				h.AssertContains(t, dockerfiles[name], "useradd --uid 1234 --gid 5678")
+				h.AssertContains(t, dockerfiles[name], `LABEL io.buildpacks.stack.id="com.example.stack"`)
+			}
+			h.AssertContains(t, dockerfiles["some/build"], "ENV CNB_USER_ID=1234 CNB_GROUP_ID=5678\n")
+			h.AssertNotContains(t, dockerfiles["some/build"], "USER ")
+			h.AssertContains(t, dockerfiles["some/run"], "USER 1234:5678\n")
+			h.AssertNotContains(t, dockerfiles["some/run"], "CNB_USER_ID")
+		})
+
+		it("fails when the images have different stacks", func() {
+			h.AssertNil(t, buildImage.SetLabel("io.buildpacks.stack.id", "com.example.stack"))
+			h.AssertNil(t, runImage.SetLabel("io.buildpacks.stack.id", "com.example.other"))
+
+			err := factory.Create(context.TODO(), config)
+			h.AssertError(t, err, "build image 'some/build' has stack 'com.example.stack' but run image 'some/run' has stack 'com.example.other'")
+		})
+
+		it("fails when the build image does not declare the CNB user", func() {
+			h.AssertNil(t, buildImage.SetLabel("io.buildpacks.stack.id", "com.example.stack"))
+			h.AssertNil(t, runImage.SetLabel("io.buildpacks.stack.id", "com.example.stack"))
+
+			err := factory.Create(context.TODO(), config)
+			h.AssertError(t, err, "build image 'some/build' does not set 'CNB_USER_ID'")
+		})
+	})
+}
@@ -0,0 +1,164 @@
+package pack
+
+import (
+	"fmt"
+
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+type ImageDiff struct {
+	RunImage         *ValueChange    `json:"run_image,omitempty" yaml:"run_image,omitempty" toml:"run_image,omitempty"`
+	RunImageDigest   *ValueChange    `json:"run_image_digest,omitempty" yaml:"run_image_digest,omitempty" toml:"run_image_digest,omitempty"`
+	RunImageTopLayer *ValueChange    `json:"run_image_top_layer,omitempty" yaml:"run_image_top_layer,omitempty" toml:"run_image_top_layer,omitempty"`
+	Launcher         *ValueChange    `json:"launcher,omitempty" yaml:"launcher,omitempty" toml:"launcher,omitempty"`
+	Config           *ValueChange    `json:"config,omitempty" yaml:"config,omitempty" toml:"config,omitempty"`
+	App              *ValueChange    `json:"app,omitempty" yaml:"app,omitempty" toml:"app,omitempty"`
+	Buildpacks       []BuildpackDiff `json:"buildpacks" yaml:"buildpacks" toml:"buildpacks"`
+}
+
+type ValueChange struct {
+	Old string `json:"old" yaml:"old" toml:"old"`
+	New string `json:"new" yaml:"new" toml:"new"`
+}
+
+type BuildpackDiff struct {
+	ID         string      `json:"id" yaml:"id" toml:"id"`
+	Status     string      `json:"status" yaml:"status" toml:"status"`
+	OldVersion string      `json:"old_version" yaml:"old_version" toml:"old_version"`
+	NewVersion string      `json:"new_version" yaml:"new_version" toml:"new_version"`
+	Layers     []LayerDiff `json:"layers" yaml:"layers" toml:"layers"`
+}
+
+type LayerDiff struct {
+	Name   string `json:"name" yaml:"name" toml:"name"`
+	Status string `json:"status" yaml:"status" toml:"status"`
+	OldSHA string `json:"old_sha" yaml:"old_sha" toml:"old_sha"`
+	NewSHA string `json:"new_sha" yaml:"new_sha" toml:"new_sha"`
+}
+
+func (d *ImageDiff) Empty() bool {
+	return d.RunImage == nil && d.RunImageDigest == nil && d.RunImageTopLayer == nil && d.Launcher == nil && d.Config == nil && d.App == nil && len(d.Buildpacks) == 0
+}
+
+// DiffImages compares the lifecycle metadata of two app images. Only differences are reported: buildpacks and layers
+// that are the same in both images are omitted.
+func (c *Client) DiffImages(nameA, nameB string, daemon bool) (*ImageDiff, error) {
+	infoA, err := c.inspectImageForDiff(nameA, daemon)
+	if err != nil {
+		return nil, err
+	}
+	infoB, err := c.inspectImageForDiff(nameB, daemon)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImageDiff{
+		RunImage:         valueChange(infoA.RunImage, infoB.RunImage),
+		RunImageDigest:   valueChange(infoA.RunImageDigest, infoB.RunImageDigest),
+		RunImageTopLayer: valueChange(infoA.RunImageTopLayer, infoB.RunImageTopLayer),
+		Launcher:         valueChange(infoA.LauncherLayerSHA, infoB.LauncherLayerSHA),
+		Config:           valueChange(infoA.ConfigLayerSHA, infoB.ConfigLayerSHA),
+		App:              valueChange(infoA.AppLayerSHA, infoB.AppLayerSHA),
+		Buildpacks:       diffBuildpacks(infoA.Buildpacks, infoB.Buildpacks),
+	}, nil
+}
+
+func (c *Client) inspectImageForDiff(name string, daemon bool) (*ImageInfo, error) {
+	info, err := c.InspectImage(name, daemon)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("image %s does not exist", style.Symbol(name))
+	}
+	return info, nil
+}
+
+func valueChange(before, after string) *ValueChange {
+	if before == after {
+		return nil
+	}
+	return &ValueChange{Old: before, New: after}
+}
+
+func diffBuildpacks(before, after []ImageBuildpackInfo) []BuildpackDiff {
+	var diffs []BuildpackDiff
+
+	newByID := map[string]ImageBuildpackInfo{}
+	for _, bp := range after {
+		newByID[bp.ID] = bp
+	}
+
+	oldIDs := map[string]bool{}
+	for _, oldBP := range before {
+		oldIDs[oldBP.ID] = true
+		newBP, ok := newByID[oldBP.ID]
+		if !ok {
+			diffs = append(diffs, BuildpackDiff{
+				ID:         oldBP.ID,
+				Status:     DiffRemoved,
+				OldVersion: oldBP.Version,
+				Layers:     diffLayers(oldBP.Layers, nil),
+			})
+			continue
+		}
+
+		layers := diffLayers(oldBP.Layers, newBP.Layers)
+		if oldBP.Version != newBP.Version || len(layers) > 0 {
+			diffs = append(diffs, BuildpackDiff{
+				ID:         oldBP.ID,
+				Status:     DiffChanged,
+				OldVersion: oldBP.Version,
+				NewVersion: newBP.Version,
+				Layers:     layers,
+			})
+		}
+	}
+
+	for _, newBP := range after {
+		if !oldIDs[newBP.ID] {
+			diffs = append(diffs, BuildpackDiff{
+				ID:         newBP.ID,
+				Status:     DiffAdded,
+				NewVersion: newBP.Version,
+				Layers:     diffLayers(nil, newBP.Layers),
+			})
+		}
+	}
+
+	return diffs
+}
+
+func diffLayers(before, after []ImageLayerInfo) []LayerDiff {
+	var diffs []LayerDiff
+
+	newByName := map[string]ImageLayerInfo{}
+	for _, layer := range after {
+		newByName[layer.Name] = layer
+	}
+
+	oldNames := map[string]bool{}
+	for _, oldLayer := range before {
+		oldNames[oldLayer.Name] = true
+		newLayer, ok := newByName[oldLayer.Name]
+		if !ok {
+			diffs = append(diffs, LayerDiff{Name: oldLayer.Name, Status: DiffRemoved, OldSHA: oldLayer.SHA})
+		} else if oldLayer.SHA != newLayer.SHA {
+			diffs = append(diffs, LayerDiff{Name: oldLayer.Name, Status: DiffChanged, OldSHA: oldLayer.SHA, NewSHA: newLayer.SHA})
+		}
+	}
+
+	for _, newLayer := range after {
+		if !oldNames[newLayer.Name] {
+			diffs = append(diffs, LayerDiff{Name: newLayer.Name, Status: DiffAdded, NewSHA: newLayer.SHA})
+		}
+	}
+
+	return diffs
+}
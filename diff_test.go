@@ -0,0 +1,179 @@
+package pack_test
+
+import (
+	"strings"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestDiffImages(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "DiffImages", testDiffImages, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testDiffImages(t *testing.T, when spec.G, it spec.S) {
+	var (
+		client         *pack.Client
+		mockFetcher    *mocks.MockFetcher
+		mockController *gomock.Controller
+		imageA         *imgtest.FakeImage
+		imageB         *imgtest.FakeImage
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+		client = pack.NewClient(&config.Config{}, mockFetcher)
+
+		imageA = imgtest.NewFakeImage(t, "some/app:a", "", "")
+		h.AssertNil(t, imageA.SetLabel("io.buildpacks.lifecycle.metadata", `{
+  "app": {"sha": "sha256:app-a"},
+  "config": {"sha": "sha256:config"},
+  "launcher": {"sha": "sha256:launcher"},
+  "buildpacks": [
+    {"key": "bp.same", "version": "1.0.0", "layers": {"same": {"sha": "sha256:same"}}},
+    {"key": "bp.changed", "version": "1.0.0", "layers": {
+      "changed": {"sha": "sha256:changed-a"},
+      "removed": {"sha": "sha256:removed"},
+      "same": {"sha": "sha256:same"}
+    }},
+    {"key": "bp.removed", "version": "1.0.0", "layers": {}}
+  ],
+  "stack": {"runImage": {"image": "some/run"}},
+  "runImage": {"topLayer": "sha256:top-layer-a", "sha": "sha256:run-a"}
+}`))
+		imageB = imgtest.NewFakeImage(t, "some/app:b", "", "")
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#DiffImages", func() {
+		it.Before(func() {
+			mockFetcher.EXPECT().FetchLocalImage("some/app:a").Return(imageA, nil)
+			mockFetcher.EXPECT().FetchLocalImage("some/app:b").Return(imageB, nil)
+		})
+
+		when("the images differ", func() {
+			it.Before(func() {
+				h.AssertNil(t, imageB.SetLabel("io.buildpacks.lifecycle.metadata", `{
+  "app": {"sha": "sha256:app-b"},
+  "config": {"sha": "sha256:config"},
+  "launcher": {"sha": "sha256:launcher"},
+  "buildpacks": [
+    {"key": "bp.same", "version": "1.0.0", "layers": {"same": {"sha": "sha256:same"}}},
+    {"key": "bp.changed", "version": "1.1.0", "layers": {
+      "added": {"sha": "sha256:added"},
+      "changed": {"sha": "sha256:changed-b"},
+      "same": {"sha": "sha256:same"}
+    }},
+    {"key": "bp.added", "version": "2.0.0", "layers": {"new": {"sha": "sha256:new"}}}
+  ],
+  "stack": {"runImage": {"image": "some/run"}},
+  "runImage": {"topLayer": "sha256:top-layer-b", "sha": "sha256:run-a"}
+}`))
+			})
+
+			it("reports the changed run image top layer, launcher, config and app layers", func() {
+				diff, err := client.DiffImages("some/app:a", "some/app:b", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, diff.RunImageTopLayer, &pack.ValueChange{Old: "sha256:top-layer-a", New: "sha256:top-layer-b"})
+				h.AssertNil(t, diff.RunImage)
+				h.AssertNil(t, diff.RunImageDigest)
+				h.AssertEq(t, diff.App, &pack.ValueChange{Old: "sha256:app-a", New: "sha256:app-b"})
+				h.AssertNil(t, diff.Launcher)
+				h.AssertNil(t, diff.Config)
+			})
+
+			it("reports changed, removed and added buildpacks and layers", func() {
+				diff, err := client.DiffImages("some/app:a", "some/app:b", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, diff.Buildpacks, []pack.BuildpackDiff{
+					{
+						ID:         "bp.changed",
+						Status:     pack.DiffChanged,
+						OldVersion: "1.0.0",
+						NewVersion: "1.1.0",
+						Layers: []pack.LayerDiff{
+							{Name: "changed", Status: pack.DiffChanged, OldSHA: "sha256:changed-a", NewSHA: "sha256:changed-b"},
+							{Name: "removed", Status: pack.DiffRemoved, OldSHA: "sha256:removed"},
+							{Name: "added", Status: pack.DiffAdded, NewSHA: "sha256:added"},
+						},
+					},
+					{ID: "bp.removed", Status: pack.DiffRemoved, OldVersion: "1.0.0"},
+					{
+						ID:         "bp.added",
+						Status:     pack.DiffAdded,
+						NewVersion: "2.0.0",
+						Layers:     []pack.LayerDiff{{Name: "new", Status: pack.DiffAdded, NewSHA: "sha256:new"}},
+					},
+				})
+			})
+		})
+
+		when("only the run image differs", func() {
+			setLabelB := func(old, new string) {
+				label, err := imageA.Label("io.buildpacks.lifecycle.metadata")
+				h.AssertNil(t, err)
+				h.AssertNil(t, imageB.SetLabel("io.buildpacks.lifecycle.metadata", strings.Replace(label, old, new, 1)))
+			}
+
+			it("reports a different run image name", func() {
+				setLabelB(`"image": "some/run"`, `"image": "other/run"`)
+
+				diff, err := client.DiffImages("some/app:a", "some/app:b", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, diff.RunImage, &pack.ValueChange{Old: "some/run", New: "other/run"})
+				h.AssertNil(t, diff.RunImageDigest)
+				h.AssertNil(t, diff.RunImageTopLayer)
+				h.AssertEq(t, diff.Empty(), false)
+			})
+
+			it("reports a different run image digest", func() {
+				setLabelB(`"sha": "sha256:run-a"`, `"sha": "sha256:run-b"`)
+
+				diff, err := client.DiffImages("some/app:a", "some/app:b", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, diff.RunImageDigest, &pack.ValueChange{Old: "sha256:run-a", New: "sha256:run-b"})
+				h.AssertNil(t, diff.RunImage)
+				h.AssertNil(t, diff.RunImageTopLayer)
+				h.AssertEq(t, diff.Empty(), false)
+			})
+		})
+
+		when("the images have the same metadata", func() {
+			it.Before(func() {
+				label, err := imageA.Label("io.buildpacks.lifecycle.metadata")
+				h.AssertNil(t, err)
+				h.AssertNil(t, imageB.SetLabel("io.buildpacks.lifecycle.metadata", label))
+			})
+
+			it("reports no differences", func() {
+				diff, err := client.DiffImages("some/app:a", "some/app:b", true)
+				h.AssertNil(t, err)
+				h.AssertEq(t, diff.Empty(), true)
+			})
+		})
+	})
+
+	when("an image does not exist", func() {
+		it("returns an error", func() {
+			imageA.Delete()
+			mockFetcher.EXPECT().FetchRemoteImage("some/app:a").Return(imageA, nil)
+
+			_, err := client.DiffImages("some/app:a", "some/app:b", false)
+			h.AssertError(t, err, "image 'some/app:a' does not exist")
+		})
+	})
+}
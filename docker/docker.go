@@ -64,6 +64,77 @@ func (d *Client) RunContainer(ctx context.Context, id string, stdout io.Writer,
 	return <-copyErr
 }
 
+func (d *Client) RunInteractiveContainer(ctx context.Context, id string, tty bool, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	resp, err := d.ContainerAttach(ctx, id, dockertypes.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "container attach")
+	}
+	defer resp.Close()
+
+	bodyChan, errChan := d.ContainerWait(ctx, id, container.WaitConditionNextExit)
+
+	if tty {
+		if inFd, isTerm := term.GetFdInfo(stdin); isTerm {
+			state, err := term.SetRawTerminal(inFd)
+			if err != nil {
+				return errors.Wrap(err, "set raw terminal")
+			}
+			defer term.RestoreTerminal(inFd, state)
+		}
+	}
+
+	if err := d.ContainerStart(ctx, id, dockertypes.ContainerStartOptions{}); err != nil {
+		return errors.Wrap(err, "container start")
+	}
+
+	if tty {
+		if outFd, isTerm := term.GetFdInfo(stdout); isTerm {
+			if size, err := term.GetWinsize(outFd); err == nil {
+				if err := d.ContainerResize(ctx, id, dockertypes.ResizeOptions{Height: uint(size.Height), Width: uint(size.Width)}); err != nil {
+					return errors.Wrap(err, "container resize")
+				}
+			}
+		}
+	}
+
+	go func() {
+		io.Copy(resp.Conn, stdin)
+		resp.CloseWrite()
+	}()
+
+	copyErr := make(chan error, 1)
+	go func() {
+		var err error
+		if tty {
+			_, err = io.Copy(stdout, resp.Reader)
+		} else {
+			_, err = stdcopy.StdCopy(stdout, stderr, resp.Reader)
+		}
+		copyErr <- err
+	}()
+
+	var statusCode int64
+	select {
+	case body := <-bodyChan:
+		statusCode = body.StatusCode
+	case err := <-errChan:
+		return err
+	}
+
+	if err := <-copyErr; err != nil {
+		return err
+	}
+	if statusCode != 0 {
+		return fmt.Errorf("failed with status code: %d", statusCode)
+	}
+	return nil
+}
+
 func (d *Client) PullImage(ctx context.Context, imageID string, stdout io.Writer) error {
 	regAuth, err := d.registryAuth(imageID)
 	if err != nil {
@@ -0,0 +1,133 @@
+package pack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/buildpack/lifecycle"
+	"github.com/buildpack/lifecycle/image"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/term"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+type ExecFactory struct {
+	Cli     Docker
+	Logger  *logging.Logger
+	Fetcher Fetcher
+	Stdin   io.Reader
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+type ExecFlags struct {
+	RepoName string
+	Command  []string
+	NoPull   bool
+}
+
+type ExecConfig struct {
+	RepoName string
+	Command  []string
+	TTY      bool
+	// Above are derived from ExecFlags
+	Cli    Docker
+	Logger *logging.Logger
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Above are copied from ExecFactory
+}
+
+func (f *ExecFactory) ExecConfigFromFlags(ctx context.Context, flags ExecFlags) (*ExecConfig, error) {
+	if len(flags.Command) == 0 {
+		return nil, errors.New("command must be specified")
+	}
+
+	var (
+		img image.Image
+		err error
+	)
+	if flags.NoPull {
+		img, err = f.Fetcher.FetchLocalImage(flags.RepoName)
+	} else {
+		f.Logger.Verbose("Pulling app image %s (use --no-pull flag to skip this step)", style.Symbol(flags.RepoName))
+		img, err = f.Fetcher.FetchUpdatedLocalImage(ctx, flags.RepoName, f.Logger.RawVerboseWriter())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if found, err := img.Found(); err != nil {
+		return nil, errors.Wrapf(err, "invalid app image %s", style.Symbol(flags.RepoName))
+	} else if !found {
+		return nil, fmt.Errorf("app image %s does not exist on the daemon", style.Symbol(flags.RepoName))
+	}
+
+	label, err := img.Label(lifecycle.MetadataLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read metadata for app image %s", style.Symbol(flags.RepoName))
+	}
+	if label == "" {
+		return nil, fmt.Errorf("image %s missing label %s -- was it built with buildpacks?", style.Symbol(flags.RepoName), style.Symbol(lifecycle.MetadataLabel))
+	}
+
+	_, tty := term.GetFdInfo(f.Stdin)
+
+	return &ExecConfig{
+		RepoName: flags.RepoName,
+		Command:  flags.Command,
+		TTY:      tty,
+		Cli:      f.Cli,
+		Logger:   f.Logger,
+		Stdin:    f.Stdin,
+		Stdout:   f.Stdout,
+		Stderr:   f.Stderr,
+	}, nil
+}
+
+func (c *ExecConfig) Run(ctx context.Context) error {
+	// The launcher joins its arguments and evaluates them with bash after sourcing
+	// the buildpack profile scripts, so the command is passed as a single quoted string.
+	ctr, err := c.Cli.ContainerCreate(ctx, &container.Config{
+		Image:        c.RepoName,
+		Cmd:          []string{shellJoin(c.Command)},
+		Tty:          c.TTY,
+		OpenStdin:    true,
+		StdinOnce:    true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Labels:       map[string]string{"author": "pack"},
+	}, &container.HostConfig{}, nil, "")
+	if err != nil {
+		return err
+	}
+	defer c.Cli.ContainerRemove(context.Background(), ctr.ID, dockertypes.ContainerRemoveOptions{Force: true})
+
+	if err := c.Cli.RunInteractiveContainer(ctx, ctr.ID, c.TTY, c.Stdin, c.Stdout, c.Stderr); err != nil {
+		return errors.Wrap(err, "run container")
+	}
+	return nil
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if shellSafe.MatchString(arg) {
+			quoted = append(quoted, arg)
+		} else {
+			quoted = append(quoted, "'"+strings.Replace(arg, "'", `'"'"'`, -1)+"'")
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,190 @@
+package pack_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestExec(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "exec", testExec, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testExec(t *testing.T, when spec.G, it spec.S) {
+	var (
+		outBuf         bytes.Buffer
+		errBuf         bytes.Buffer
+		inBuf          bytes.Buffer
+		logger         *logging.Logger
+		mockController *gomock.Controller
+		mockDocker     *mocks.MockDocker
+		mockFetcher    *mocks.MockFetcher
+		mockImage      *mocks.MockImage
+		factory        *pack.ExecFactory
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = mocks.NewMockDocker(mockController)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+		mockImage = mocks.NewMockImage(mockController)
+		logger = logging.NewLogger(&outBuf, &errBuf, true, false)
+		factory = &pack.ExecFactory{
+			Cli:     mockDocker,
+			Logger:  logger,
+			Fetcher: mockFetcher,
+			Stdin:   &inBuf,
+			Stdout:  &outBuf,
+			Stderr:  &errBuf,
+		}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#ExecConfigFromFlags", func() {
+		when("the app image exists", func() {
+			it.Before(func() {
+				mockImage.EXPECT().Found().Return(true, nil)
+				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").Return(`{"app":{"sha":"some-sha"}}`, nil)
+			})
+
+			it("pulls the app image by default", func() {
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/app", gomock.Any()).Return(mockImage, nil)
+
+				cfg, err := factory.ExecConfigFromFlags(context.TODO(), pack.ExecFlags{
+					RepoName: "some/app",
+					Command:  []string{"ls", "-la"},
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.RepoName, "some/app")
+				h.AssertEq(t, cfg.Command, []string{"ls", "-la"})
+				h.AssertSameInstance(t, cfg.Cli, mockDocker)
+				h.AssertSameInstance(t, cfg.Stdin, &inBuf)
+			})
+
+			it("does not allocate a TTY when stdin is not a terminal", func() {
+				mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/app", gomock.Any()).Return(mockImage, nil)
+
+				cfg, err := factory.ExecConfigFromFlags(context.TODO(), pack.ExecFlags{
+					RepoName: "some/app",
+					Command:  []string{"bash"},
+				})
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.TTY, false)
+			})
+
+			it("uses the local image when --no-pull is provided", func() {
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(mockImage, nil)
+
+				_, err := factory.ExecConfigFromFlags(context.TODO(), pack.ExecFlags{
+					RepoName: "some/app",
+					Command:  []string{"bash"},
+					NoPull:   true,
+				})
+				h.AssertNil(t, err)
+			})
+		})
+
+		when("the app image does not exist", func() {
+			it("returns an error", func() {
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(mockImage, nil)
+				mockImage.EXPECT().Found().Return(false, nil)
+
+				_, err := factory.ExecConfigFromFlags(context.TODO(), pack.ExecFlags{
+					RepoName: "some/app",
+					Command:  []string{"bash"},
+					NoPull:   true,
+				})
+				h.AssertError(t, err, "app image 'some/app' does not exist on the daemon")
+			})
+		})
+
+		when("the image was not built with buildpacks", func() {
+			it("returns an error", func() {
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(mockImage, nil)
+				mockImage.EXPECT().Found().Return(true, nil)
+				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").Return("", nil)
+
+				_, err := factory.ExecConfigFromFlags(context.TODO(), pack.ExecFlags{
+					RepoName: "some/app",
+					Command:  []string{"bash"},
+					NoPull:   true,
+				})
+				h.AssertError(t, err, "image 'some/app' missing label 'io.buildpacks.lifecycle.metadata'")
+			})
+		})
+
+		when("no command is provided", func() {
+			it("returns an error", func() {
+				_, err := factory.ExecConfigFromFlags(context.TODO(), pack.ExecFlags{
+					RepoName: "some/app",
+				})
+				h.AssertError(t, err, "command must be specified")
+			})
+		})
+	})
+
+	when("#Run", func() {
+		var (
+			subject *pack.ExecConfig
+			ctr     container.ContainerCreateCreatedBody
+		)
+
+		it.Before(func() {
+			subject = &pack.ExecConfig{
+				RepoName: "some/app",
+				Command:  []string{"echo", "hello world", "it's"},
+				TTY:      true,
+				Cli:      mockDocker,
+				Logger:   logger,
+				Stdin:    &inBuf,
+				Stdout:   &outBuf,
+				Stderr:   &errBuf,
+			}
+			ctr = container.ContainerCreateCreatedBody{ID: "29aef5a011dd"}
+		})
+
+		it("runs the quoted command through the launcher and cleans up", func() {
+			mockDocker.EXPECT().ContainerCreate(gomock.Any(), &container.Config{
+				Image:        "some/app",
+				Cmd:          []string{`echo 'hello world' 'it'"'"'s'`},
+				Tty:          true,
+				OpenStdin:    true,
+				StdinOnce:    true,
+				AttachStdin:  true,
+				AttachStdout: true,
+				AttachStderr: true,
+				Labels:       map[string]string{"author": "pack"},
+			}, &container.HostConfig{}, nil, "").Return(ctr, nil)
+			mockDocker.EXPECT().RunInteractiveContainer(gomock.Any(), ctr.ID, true, &inBuf, &outBuf, &errBuf).Return(nil)
+			mockDocker.EXPECT().ContainerRemove(gomock.Any(), ctr.ID, types.ContainerRemoveOptions{Force: true})
+
+			h.AssertNil(t, subject.Run(context.TODO()))
+		})
+
+		it("returns an error when the command fails", func() {
+			mockDocker.EXPECT().ContainerCreate(gomock.Any(), gomock.Any(), gomock.Any(), nil, "").Return(ctr, nil)
+			mockDocker.EXPECT().RunInteractiveContainer(gomock.Any(), ctr.ID, true, gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(errors.New("failed with status code: 3"))
+			mockDocker.EXPECT().ContainerRemove(gomock.Any(), ctr.ID, types.ContainerRemoveOptions{Force: true})
+
+			h.AssertError(t, subject.Run(context.TODO()), "run container: failed with status code: 3")
+		})
+	})
+}
@@ -29,4 +29,5 @@ require (
 	golang.org/x/crypto v0.0.0-20190228161510-8dd112bcdc25 // indirect
 	golang.org/x/sys v0.0.0-20190306220723-b294cbcfc56d // indirect
 	google.golang.org/genproto v0.0.0-20190306222511-6e86cb5d2f12 // indirect
+	gopkg.in/yaml.v2 v2.2.1
 )
@@ -1,24 +1,47 @@
 package pack
 
 import (
+	"time"
+
 	"github.com/buildpack/lifecycle/image"
 	"github.com/buildpack/pack/builder"
 	"github.com/pkg/errors"
 )
 
 type BuilderInfo struct {
-	Stack                string
-	RunImage             string
-	RunImageMirrors      []string
-	LocalRunImageMirrors []string
-	Buildpacks           []BuildpackInfo
-	Groups               [][]BuildpackInfo
+	Description          string                 `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Stack                string                 `json:"stack" yaml:"stack" toml:"stack"`
+	RunImage             string                 `json:"run_image" yaml:"run_image" toml:"run_image"`
+	RunImageMirrors      []string               `json:"run_image_mirrors" yaml:"run_image_mirrors" toml:"run_image_mirrors"`
+	LocalRunImageMirrors []string               `json:"local_run_image_mirrors" yaml:"local_run_image_mirrors" toml:"local_run_image_mirrors"`
+	Buildpacks           []BuildpackInfo        `json:"buildpacks" yaml:"buildpacks" toml:"buildpacks"`
+	Groups               []BuildpackGroupInfo   `json:"groups" yaml:"groups" toml:"groups"`
+	LifecycleVersion     string                 `json:"lifecycle_version,omitempty" yaml:"lifecycle_version,omitempty" toml:"lifecycle_version,omitempty"`
+	CreatedBy            *CreatorInfo           `json:"created_by,omitempty" yaml:"created_by,omitempty" toml:"created_by,omitempty"`
+	Metadata             map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty" toml:"metadata,omitempty"`
+}
+
+type CreatorInfo struct {
+	PackVersion      string    `json:"pack_version" yaml:"pack_version" toml:"pack_version"`
+	Created          time.Time `json:"created" yaml:"created" toml:"created"`
+	BuildImage       string    `json:"build_image" yaml:"build_image" toml:"build_image"`
+	BuildImageDigest string    `json:"build_image_digest,omitempty" yaml:"build_image_digest,omitempty" toml:"build_image_digest,omitempty"`
 }
 
 type BuildpackInfo struct {
-	ID      string
-	Version string
-	Latest  bool
+	ID          string   `json:"id" yaml:"id" toml:"id"`
+	Version     string   `json:"version" yaml:"version" toml:"version"`
+	Latest      bool     `json:"latest" yaml:"latest" toml:"latest"`
+	Name        string   `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Homepage    string   `json:"homepage,omitempty" yaml:"homepage,omitempty" toml:"homepage,omitempty"`
+	Stacks      []string `json:"stacks,omitempty" yaml:"stacks,omitempty" toml:"stacks,omitempty"`
+	Optional    bool     `json:"optional,omitempty" yaml:"optional,omitempty" toml:"optional,omitempty"`
+	Commit      string   `json:"commit,omitempty" yaml:"commit,omitempty" toml:"commit,omitempty"`
+}
+
+type BuildpackGroupInfo struct {
+	Buildpacks []BuildpackInfo `json:"buildpacks" yaml:"buildpacks" toml:"buildpacks"`
 }
 
 func (c *Client) InspectBuilder(name string, daemon bool) (*BuilderInfo, error) {
@@ -64,27 +87,55 @@ func (c *Client) InspectBuilder(name string, daemon bool) (*BuilderInfo, error)
 		buildpacks = append(buildpacks, buildpackMetadataToInfo(bp))
 	}
 
-	groups := make([][]BuildpackInfo, len(metadata.Groups))
+	groups := make([]BuildpackGroupInfo, len(metadata.Groups))
 	for i, group := range metadata.Groups {
 		for _, bp := range group.Buildpacks {
-			groups[i] = append(groups[i], buildpackMetadataToInfo(bp))
+			groups[i].Buildpacks = append(groups[i].Buildpacks, buildpackMetadataToInfo(bp))
+		}
+	}
+
+	lifecycleVersion := ""
+	if metadata.Lifecycle != nil {
+		lifecycleVersion = metadata.Lifecycle.Version
+		if lifecycleVersion == "" {
+			lifecycleVersion = "unknown"
+		}
+	}
+
+	var createdBy *CreatorInfo
+	if c := metadata.CreatedBy; c != nil {
+		createdBy = &CreatorInfo{
+			PackVersion:      c.PackVersion,
+			Created:          c.Created,
+			BuildImage:       c.BuildImage,
+			BuildImageDigest: c.BuildImageDigest,
 		}
 	}
 
 	return &BuilderInfo{
+		Description:          metadata.Description,
 		Stack:                stackID,
 		RunImage:             metadata.Stack.RunImage.Image,
 		RunImageMirrors:      metadata.Stack.RunImage.Mirrors,
 		LocalRunImageMirrors: localMirrors,
 		Buildpacks:           buildpacks,
 		Groups:               groups,
+		LifecycleVersion:     lifecycleVersion,
+		CreatedBy:            createdBy,
+		Metadata:             metadata.Custom,
 	}, nil
 }
 
 func buildpackMetadataToInfo(bp builder.BuildpackMetadata) BuildpackInfo {
 	return BuildpackInfo{
-		ID:      bp.ID,
-		Version: bp.Version,
-		Latest:  bp.Latest,
+		ID:          bp.ID,
+		Version:     bp.Version,
+		Latest:      bp.Latest,
+		Name:        bp.Name,
+		Description: bp.Description,
+		Homepage:    bp.Homepage,
+		Stacks:      bp.Stacks,
+		Optional:    bp.Optional,
+		Commit:      bp.Commit,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	imgtest "github.com/buildpack/lifecycle/testhelpers"
 	"github.com/fatih/color"
@@ -74,6 +75,15 @@ func testInspectBuilder(t *testing.T, when spec.G, it spec.S) {
     {
       "id": "test.bp.one",
       "version": "1.0.0",
+      "latest": true,
+      "name": "Test Buildpack One",
+      "description": "Provides one thing",
+      "homepage": "https://example.com/one",
+      "stacks": ["test.stack.id"]
+    },
+    {
+      "id": "test.bp.two",
+      "version": "2.0.0",
       "latest": true
     }
   ],
@@ -84,10 +94,28 @@ func testInspectBuilder(t *testing.T, when spec.G, it spec.S) {
           "id": "test.bp.one",
           "version": "1.0.0",
           "latest": true
+        },
+        {
+          "id": "test.bp.two",
+          "version": "2.0.0",
+          "optional": true
         }
       ]
     }
-  ]
+  ],
+  "lifecycle": {
+    "version": "0.2.0"
+  },
+  "description": "Some builder for some apps",
+  "createdBy": {
+    "packVersion": "1.2.3",
+    "created": "2019-04-01T12:00:00Z",
+    "buildImage": "some/build-image",
+    "buildImageDigest": "sha256:some-digest"
+  },
+  "metadata": {
+    "maintainer": "some-team@example.com"
+  }
 }`))
 					})
 
@@ -113,20 +141,62 @@ func testInspectBuilder(t *testing.T, when spec.G, it spec.S) {
 						builderInfo, err := client.InspectBuilder("some/builder", useDaemon)
 						h.AssertNil(t, err)
 						h.AssertEq(t, builderInfo.Buildpacks[0], pack.BuildpackInfo{
-							ID:      "test.bp.one",
-							Version: "1.0.0",
+							ID:          "test.bp.one",
+							Version:     "1.0.0",
+							Latest:      true,
+							Name:        "Test Buildpack One",
+							Description: "Provides one thing",
+							Homepage:    "https://example.com/one",
+							Stacks:      []string{"test.stack.id"},
+						})
+					})
+
+					it("tolerates buildpacks without details", func() {
+						builderInfo, err := client.InspectBuilder("some/builder", useDaemon)
+						h.AssertNil(t, err)
+						h.AssertEq(t, builderInfo.Buildpacks[1], pack.BuildpackInfo{
+							ID:      "test.bp.two",
+							Version: "2.0.0",
 							Latest:  true,
 						})
 					})
 
+					it("sets the description, metadata and provenance", func() {
+						builderInfo, err := client.InspectBuilder("some/builder", useDaemon)
+						h.AssertNil(t, err)
+						h.AssertEq(t, builderInfo.Description, "Some builder for some apps")
+						h.AssertEq(t, builderInfo.Metadata, map[string]interface{}{"maintainer": "some-team@example.com"})
+						h.AssertEq(t, builderInfo.CreatedBy, &pack.CreatorInfo{
+							PackVersion:      "1.2.3",
+							Created:          time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC),
+							BuildImage:       "some/build-image",
+							BuildImageDigest: "sha256:some-digest",
+						})
+					})
+
+					it("sets the lifecycle version", func() {
+						builderInfo, err := client.InspectBuilder("some/builder", useDaemon)
+						h.AssertNil(t, err)
+						h.AssertEq(t, builderInfo.LifecycleVersion, "0.2.0")
+					})
+
 					it("sets the groups", func() {
 						builderInfo, err := client.InspectBuilder("some/builder", useDaemon)
 						h.AssertNil(t, err)
-						h.AssertEq(t, builderInfo.Groups[0], []pack.BuildpackInfo{{
-							ID:      "test.bp.one",
-							Version: "1.0.0",
-							Latest:  true,
-						}})
+						h.AssertEq(t, builderInfo.Groups[0], pack.BuildpackGroupInfo{
+							Buildpacks: []pack.BuildpackInfo{
+								{
+									ID:      "test.bp.one",
+									Version: "1.0.0",
+									Latest:  true,
+								},
+								{
+									ID:       "test.bp.two",
+									Version:  "2.0.0",
+									Optional: true,
+								},
+							},
+						})
 					})
 				})
 			})
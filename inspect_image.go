@@ -0,0 +1,129 @@
+package pack
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/buildpack/lifecycle"
+	"github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/stack"
+	"github.com/buildpack/pack/style"
+)
+
+type ImageInfo struct {
+	Stack                string               `json:"stack" yaml:"stack" toml:"stack"`
+	RunImage             string               `json:"run_image" yaml:"run_image" toml:"run_image"`
+	RunImageMirrors      []string             `json:"run_image_mirrors" yaml:"run_image_mirrors" toml:"run_image_mirrors"`
+	LocalRunImageMirrors []string             `json:"local_run_image_mirrors" yaml:"local_run_image_mirrors" toml:"local_run_image_mirrors"`
+	RunImageTopLayer     string               `json:"run_image_top_layer" yaml:"run_image_top_layer" toml:"run_image_top_layer"`
+	RunImageDigest       string               `json:"run_image_digest" yaml:"run_image_digest" toml:"run_image_digest"`
+	Buildpacks           []ImageBuildpackInfo `json:"buildpacks" yaml:"buildpacks" toml:"buildpacks"`
+	AppLayerSHA          string               `json:"app_layer_sha" yaml:"app_layer_sha" toml:"app_layer_sha"`
+	ConfigLayerSHA       string               `json:"config_layer_sha" yaml:"config_layer_sha" toml:"config_layer_sha"`
+	LauncherLayerSHA     string               `json:"launcher_layer_sha" yaml:"launcher_layer_sha" toml:"launcher_layer_sha"`
+}
+
+type ImageBuildpackInfo struct {
+	ID      string           `json:"id" yaml:"id" toml:"id"`
+	Version string           `json:"version" yaml:"version" toml:"version"`
+	Layers  []ImageLayerInfo `json:"layers" yaml:"layers" toml:"layers"`
+}
+
+type ImageLayerInfo struct {
+	Name   string `json:"name" yaml:"name" toml:"name"`
+	SHA    string `json:"sha" yaml:"sha" toml:"sha"`
+	Build  bool   `json:"build" yaml:"build" toml:"build"`
+	Launch bool   `json:"launch" yaml:"launch" toml:"launch"`
+	Cache  bool   `json:"cache" yaml:"cache" toml:"cache"`
+}
+
+func (c *Client) InspectImage(name string, daemon bool) (*ImageInfo, error) {
+	var (
+		img image.Image
+		err error
+	)
+
+	if daemon {
+		img, err = c.fetcher.FetchLocalImage(name)
+	} else {
+		img, err = c.fetcher.FetchRemoteImage(name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get image '%s'", name)
+	}
+
+	if found, err := img.Found(); err != nil {
+		return nil, errors.Wrapf(err, "failed to find image '%s'", name)
+	} else if !found {
+		return nil, nil
+	}
+
+	stackID, err := img.Label(stack.IDLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get stackID for image '%s'", name)
+	}
+
+	label, err := img.Label(lifecycle.MetadataLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get metadata for image '%s'", name)
+	}
+	if label == "" {
+		return nil, fmt.Errorf("image %s missing label %s -- was it built with buildpacks?", style.Symbol(name), style.Symbol(lifecycle.MetadataLabel))
+	}
+
+	var metadata lifecycle.AppImageMetadata
+	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse metadata for image '%s'", name)
+	}
+
+	var localMirrors []string
+	if runImage := c.config.GetRunImage(metadata.Stack.RunImage.Image); runImage != nil {
+		localMirrors = runImage.Mirrors
+	}
+
+	var buildpacks []ImageBuildpackInfo
+	for _, bp := range metadata.Buildpacks {
+		buildpacks = append(buildpacks, ImageBuildpackInfo{
+			ID:      bp.ID,
+			Version: bp.Version,
+			Layers:  layerMetadataToInfo(bp.Layers),
+		})
+	}
+
+	return &ImageInfo{
+		Stack:                stackID,
+		RunImage:             metadata.Stack.RunImage.Image,
+		RunImageMirrors:      metadata.Stack.RunImage.Mirrors,
+		LocalRunImageMirrors: localMirrors,
+		RunImageTopLayer:     metadata.RunImage.TopLayer,
+		RunImageDigest:       metadata.RunImage.SHA,
+		Buildpacks:           buildpacks,
+		AppLayerSHA:          metadata.App.SHA,
+		ConfigLayerSHA:       metadata.Config.SHA,
+		LauncherLayerSHA:     metadata.Launcher.SHA,
+	}, nil
+}
+
+func layerMetadataToInfo(layers map[string]lifecycle.LayerMetadata) []ImageLayerInfo {
+	var names []string
+	for name := range layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var infos []ImageLayerInfo
+	for _, name := range names {
+		layer := layers[name]
+		infos = append(infos, ImageLayerInfo{
+			Name:   name,
+			SHA:    layer.SHA,
+			Build:  layer.Build,
+			Launch: layer.Launch,
+			Cache:  layer.Cache,
+		})
+	}
+	return infos
+}
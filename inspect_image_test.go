@@ -0,0 +1,155 @@
+package pack_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestInspectImage(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "InspectImage", testInspectImage, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testInspectImage(t *testing.T, when spec.G, it spec.S) {
+	var (
+		client         *pack.Client
+		mockFetcher    *mocks.MockFetcher
+		mockController *gomock.Controller
+		appImage       *imgtest.FakeImage
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+		client = pack.NewClient(&config.Config{
+			RunImages: []config.RunImage{
+				{Image: "some/run-image", Mirrors: []string{"some/local-mirror"}},
+			},
+		}, mockFetcher)
+		appImage = imgtest.NewFakeImage(t, "some/app", "", "")
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("the image exists", func() {
+		for _, useDaemon := range []bool{true, false} {
+			when(fmt.Sprintf("daemon is %t", useDaemon), func() {
+				it.Before(func() {
+					if useDaemon {
+						mockFetcher.EXPECT().FetchLocalImage("some/app").Return(appImage, nil)
+					} else {
+						mockFetcher.EXPECT().FetchRemoteImage("some/app").Return(appImage, nil)
+					}
+				})
+
+				when("the image has a metadata label", func() {
+					var info *pack.ImageInfo
+
+					it.Before(func() {
+						h.AssertNil(t, appImage.SetLabel("io.buildpacks.stack.id", "test.stack.id"))
+						h.AssertNil(t, appImage.SetLabel("io.buildpacks.lifecycle.metadata", `{
+  "app": {"sha": "sha256:app"},
+  "config": {"sha": "sha256:config"},
+  "launcher": {"sha": "sha256:launcher"},
+  "buildpacks": [
+    {
+      "key": "test.bp.one",
+      "version": "1.0.0",
+      "layers": {
+        "node_modules": {"sha": "sha256:modules", "launch": true, "cache": true},
+        "nodejs": {"sha": "sha256:nodejs", "build": true, "launch": true}
+      }
+    }
+  ],
+  "runImage": {"topLayer": "sha256:top-layer", "sha": "sha256:run-digest"},
+  "stack": {
+    "runImage": {
+      "image": "some/run-image",
+      "mirrors": ["gcr.io/some/default"]
+    }
+  }
+}`))
+						var err error
+						info, err = client.InspectImage("some/app", useDaemon)
+						h.AssertNil(t, err)
+					})
+
+					it("sets the stack and run images", func() {
+						h.AssertEq(t, info.Stack, "test.stack.id")
+						h.AssertEq(t, info.RunImage, "some/run-image")
+						h.AssertEq(t, info.RunImageMirrors, []string{"gcr.io/some/default"})
+						h.AssertEq(t, info.LocalRunImageMirrors, []string{"some/local-mirror"})
+					})
+
+					it("sets the run image the app was built on", func() {
+						h.AssertEq(t, info.RunImageTopLayer, "sha256:top-layer")
+						h.AssertEq(t, info.RunImageDigest, "sha256:run-digest")
+					})
+
+					it("sets the buildpacks and their layers in order", func() {
+						h.AssertEq(t, info.Buildpacks, []pack.ImageBuildpackInfo{{
+							ID:      "test.bp.one",
+							Version: "1.0.0",
+							Layers: []pack.ImageLayerInfo{
+								{Name: "node_modules", SHA: "sha256:modules", Launch: true, Cache: true},
+								{Name: "nodejs", SHA: "sha256:nodejs", Build: true, Launch: true},
+							},
+						}})
+					})
+
+					it("sets the app, config and launcher layers", func() {
+						h.AssertEq(t, info.AppLayerSHA, "sha256:app")
+						h.AssertEq(t, info.ConfigLayerSHA, "sha256:config")
+						h.AssertEq(t, info.LauncherLayerSHA, "sha256:launcher")
+					})
+				})
+
+				when("the image has no metadata label", func() {
+					it("returns an error", func() {
+						_, err := client.InspectImage("some/app", useDaemon)
+						h.AssertError(t, err, "image 'some/app' missing label 'io.buildpacks.lifecycle.metadata' -- was it built with buildpacks?")
+					})
+				})
+			})
+		}
+	})
+
+	when("fetcher fails to fetch the image", func() {
+		it.Before(func() {
+			mockFetcher.EXPECT().FetchRemoteImage("some/app").Return(nil, errors.New("some-error"))
+		})
+
+		it("returns an error", func() {
+			_, err := client.InspectImage("some/app", false)
+			h.AssertError(t, err, "failed to get image 'some/app': some-error")
+		})
+	})
+
+	when("the image does not exist", func() {
+		it.Before(func() {
+			notFoundImage := imgtest.NewFakeImage(t, "", "", "")
+			notFoundImage.Delete()
+			mockFetcher.EXPECT().FetchLocalImage("some/app").Return(notFoundImage, nil)
+		})
+
+		it("returns nil info", func() {
+			info, err := client.InspectImage("some/app", true)
+			h.AssertNil(t, err)
+			h.AssertNil(t, info)
+		})
+	})
+}
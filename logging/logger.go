@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -25,6 +26,18 @@ func NewLogger(stdout, stderr io.Writer, verbose, timestamps bool) *Logger {
 	}
 }
 
+// Buffered returns a logger with the verbosity and timestamps of l that keeps what is logged to it, and a function
+// writing all of it to the writers of l at once. It keeps the output of each of several concurrent tasks together.
+func (l *Logger) Buffered() (*Logger, func()) {
+	var outBuf, errBuf bytes.Buffer
+	timestamps := l.out.log.Flags() != 0
+	buffered := NewLogger(&outBuf, &errBuf, l.verbose, timestamps)
+	return buffered, func() {
+		l.out.rawOut.Write(outBuf.Bytes())
+		l.err.rawOut.Write(errBuf.Bytes())
+	}
+}
+
 func (l *Logger) printf(w *logWriter, format string, a ...interface{}) {
 	w.Write([]byte(fmt.Sprintf(format+"\n", a...)))
 }
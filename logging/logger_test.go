@@ -154,6 +154,31 @@ func testLogging(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("#Buffered", func() {
+		it("writes what was logged to the buffered logger when flushed", func() {
+			logger = logging.NewLogger(&outBuf, &errBuf, true, false)
+			buffered, flush := logger.Buffered()
+			buffered.Info("Some info")
+			buffered.Verbose("Some verbose output")
+			buffered.Error("Something went wrong!")
+			h.AssertEq(t, outBuf.String(), "")
+			h.AssertEq(t, errBuf.String(), "")
+
+			flush()
+			h.AssertContains(t, outBuf.String(), "Some info\n")
+			h.AssertContains(t, outBuf.String(), "Some verbose output\n")
+			h.AssertContains(t, errBuf.String(), style.Error("ERROR: ")+"Something went wrong!\n")
+		})
+
+		it("keeps the verbosity of the logger", func() {
+			logger = logging.NewLogger(&outBuf, &errBuf, false, false)
+			buffered, flush := logger.Buffered()
+			buffered.Verbose("Some verbose output")
+			flush()
+			h.AssertEq(t, outBuf.String(), "")
+		})
+	})
+
 	when("#WithPrefix", func() {
 		it("returns prefixed writer", func() {
 			writer := logging.NewLogger(&outBuf, &errBuf, true, false).VerboseWriter()
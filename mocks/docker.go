@@ -128,6 +128,19 @@ func (mr *MockDockerMockRecorder) ImageInspectWithRaw(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageInspectWithRaw", reflect.TypeOf((*MockDocker)(nil).ImageInspectWithRaw), arg0, arg1)
 }
 
+// ImageLoad mocks base method
+func (m *MockDocker) ImageLoad(arg0 context.Context, arg1 io.Reader, arg2 bool) (types.ImageLoadResponse, error) {
+	ret := m.ctrl.Call(m, "ImageLoad", arg0, arg1, arg2)
+	ret0, _ := ret[0].(types.ImageLoadResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImageLoad indicates an expected call of ImageLoad
+func (mr *MockDockerMockRecorder) ImageLoad(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageLoad", reflect.TypeOf((*MockDocker)(nil).ImageLoad), arg0, arg1, arg2)
+}
+
 // ImageRemove mocks base method
 func (m *MockDocker) ImageRemove(arg0 context.Context, arg1 string, arg2 types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
 	ret := m.ctrl.Call(m, "ImageRemove", arg0, arg1, arg2)
@@ -141,6 +154,19 @@ func (mr *MockDockerMockRecorder) ImageRemove(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageRemove", reflect.TypeOf((*MockDocker)(nil).ImageRemove), arg0, arg1, arg2)
 }
 
+// ImageSave mocks base method
+func (m *MockDocker) ImageSave(arg0 context.Context, arg1 []string) (io.ReadCloser, error) {
+	ret := m.ctrl.Call(m, "ImageSave", arg0, arg1)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImageSave indicates an expected call of ImageSave
+func (mr *MockDockerMockRecorder) ImageSave(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageSave", reflect.TypeOf((*MockDocker)(nil).ImageSave), arg0, arg1)
+}
+
 // PullImage mocks base method
 func (m *MockDocker) PullImage(arg0 context.Context, arg1 string, arg2 io.Writer) error {
 	ret := m.ctrl.Call(m, "PullImage", arg0, arg1, arg2)
@@ -165,6 +191,18 @@ func (mr *MockDockerMockRecorder) RunContainer(arg0, arg1, arg2, arg3 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunContainer", reflect.TypeOf((*MockDocker)(nil).RunContainer), arg0, arg1, arg2, arg3)
 }
 
+// RunInteractiveContainer mocks base method
+func (m *MockDocker) RunInteractiveContainer(arg0 context.Context, arg1 string, arg2 bool, arg3 io.Reader, arg4, arg5 io.Writer) error {
+	ret := m.ctrl.Call(m, "RunInteractiveContainer", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunInteractiveContainer indicates an expected call of RunInteractiveContainer
+func (mr *MockDockerMockRecorder) RunInteractiveContainer(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInteractiveContainer", reflect.TypeOf((*MockDocker)(nil).RunInteractiveContainer), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
 // VolumeRemove mocks base method
 func (m *MockDocker) VolumeRemove(arg0 context.Context, arg1 string, arg2 bool) error {
 	ret := m.ctrl.Call(m, "VolumeRemove", arg0, arg1, arg2)
@@ -0,0 +1,226 @@
+package pack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/style"
+)
+
+type PackageBuildpackFlags struct {
+	Output     string
+	ConfigPath string
+	Image      bool
+}
+
+type PackageBuildpackConfig struct {
+	Output     string
+	Image      bool
+	Buildpacks []buildpack.Buildpack
+}
+
+type PackageFactory struct {
+	Logger           *logging.Logger
+	Docker           Docker
+	BuildpackFetcher BuildpackFetcher
+}
+
+type packageTOML struct {
+	Buildpacks []buildpack.Buildpack `toml:"buildpacks"`
+}
+
+func (f *PackageFactory) PackageBuildpackConfigFromFlags(flags PackageBuildpackFlags) (PackageBuildpackConfig, error) {
+	if !flags.Image {
+		if ext := filepath.Ext(flags.Output); ext != ".cnb" && ext != ".tgz" {
+			return PackageBuildpackConfig{}, fmt.Errorf("output %s must be a .cnb or .tgz file, or an image name with --image", style.Symbol(flags.Output))
+		}
+	}
+
+	packageConfig := packageTOML{}
+	if _, err := toml.DecodeFile(flags.ConfigPath, &packageConfig); err != nil {
+		return PackageBuildpackConfig{}, fmt.Errorf(`failed to decode package config from file %s: %s`, flags.ConfigPath, err)
+	}
+	if len(packageConfig.Buildpacks) == 0 {
+		return PackageBuildpackConfig{}, fmt.Errorf("package config %s does not list any buildpacks", style.Symbol(flags.ConfigPath))
+	}
+
+	config := PackageBuildpackConfig{
+		Output: flags.Output,
+		Image:  flags.Image,
+	}
+	for _, bp := range packageConfig.Buildpacks {
+		fetched, err := f.BuildpackFetcher.FetchBuildpack(filepath.Dir(flags.ConfigPath), bp)
+		if err != nil {
+			return PackageBuildpackConfig{}, err
+		}
+		config.Buildpacks = append(config.Buildpacks, fetched)
+	}
+	return config, nil
+}
+
+// Package validates the buildpacks of a package and writes them to a gzipped tar archive, or to an image when
+// config.Image is set. A package with a single buildpack has it at the root of the archive, otherwise each buildpack
+// is in a <id>/<version> directory. Images always contain the buildpacks in /buildpacks/<id>/<version>.
+func (f *PackageFactory) Package(ctx context.Context, config PackageBuildpackConfig) error {
+	var refs []string
+	for i, bp := range config.Buildpacks {
+		data, err := packagedBuildpackData(bp)
+		if err != nil {
+			return err
+		}
+		ref := data.BP.ID + "@" + data.BP.Version
+		if containsString(refs, ref) {
+			return fmt.Errorf("buildpack %s is in the package more than once", style.Symbol(ref))
+		}
+		refs = append(refs, ref)
+		config.Buildpacks[i].ID = data.BP.ID
+		config.Buildpacks[i].Version = data.BP.Version
+	}
+
+	if config.Image {
+		return f.packageImage(ctx, config)
+	}
+	return f.packageArchive(config)
+}
+
+func (f *PackageFactory) packageArchive(config PackageBuildpackConfig) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(config.Output), filepath.Base(config.Output))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	gzw := gzip.NewWriter(tmpFile)
+	tw := tar.NewWriter(gzw)
+	for _, bp := range config.Buildpacks {
+		tarDir := ""
+		if len(config.Buildpacks) > 1 {
+			tarDir = path.Join(bp.EscapedID(), bp.Version)
+		}
+		f.Logger.Verbose("Adding buildpack %s", style.Symbol(bp.ID+"@"+bp.Version))
+		if err := archive.WriteDirToTar(tw, bp.Dir, tarDir, 0, 0); err != nil {
+			return errors.Wrapf(err, "adding buildpack %s to package", style.Symbol(bp.ID))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gzw.Close(); err != nil {
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	// temporary files are only readable by their owner, which the package should not inherit
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), config.Output)
+}
+
+func (f *PackageFactory) packageImage(ctx context.Context, config PackageBuildpackConfig) error {
+	tmpDir, err := ioutil.TempDir("", "package-buildpack")
+	if err != nil {
+		return fmt.Errorf(`failed to create temporary directory: %s`, err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	contextTar := filepath.Join(tmpDir, "context.tar")
+	if err := f.imageBuildContext(contextTar, config.Buildpacks); err != nil {
+		return errors.Wrap(err, "creating image build context")
+	}
+	buildContext, err := os.Open(contextTar)
+	if err != nil {
+		return err
+	}
+	defer buildContext.Close()
+
+	res, err := f.Docker.ImageBuild(ctx, buildContext, dockertypes.ImageBuildOptions{
+		Tags:        []string{config.Output},
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating image %s", style.Symbol(config.Output))
+	}
+	defer res.Body.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(res.Body, f.Logger.VerboseWriter(), 0, false, nil); err != nil {
+		return errors.Wrapf(err, "creating image %s", style.Symbol(config.Output))
+	}
+	return nil
+}
+
+// imageBuildContext writes a build context whose Dockerfile copies the buildpacks into a single layer, which is
+// where buildpack.Fetcher looks for them in docker:// buildpacks.
+func (f *PackageFactory) imageBuildContext(tarFile string, buildpacks []buildpack.Buildpack) error {
+	fh, err := os.Create(tarFile)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	tw := tar.NewWriter(fh)
+	dockerfile := "FROM scratch\nCOPY buildpacks /buildpacks\n"
+	if err := tw.WriteHeader(&tar.Header{Name: "Dockerfile", Size: int64(len(dockerfile)), Mode: 0644, ModTime: archive.NormalizedDateTime}); err != nil {
+		return err
+	}
+	if _, err := tw.Write([]byte(dockerfile)); err != nil {
+		return err
+	}
+	for _, bp := range buildpacks {
+		f.Logger.Verbose("Adding buildpack %s", style.Symbol(bp.ID+"@"+bp.Version))
+		if err := archive.WriteDirToTar(tw, bp.Dir, path.Join("buildpacks", bp.EscapedID(), bp.Version), 0, 0); err != nil {
+			return errors.Wrapf(err, "adding buildpack %s to package", style.Symbol(bp.ID))
+		}
+	}
+	return tw.Close()
+}
+
+// packagedBuildpackData reads the buildpack.toml of a buildpack to package, and ensures it has executable
+// bin/detect and bin/build scripts.
+func packagedBuildpackData(bp buildpack.Buildpack) (*BuildpackData, error) {
+	data := &BuildpackData{}
+	if _, err := toml.DecodeFile(filepath.Join(bp.Dir, "buildpack.toml"), data); err != nil {
+		return nil, errors.Wrapf(err, "reading buildpack.toml from buildpack: %s", bp.Dir)
+	}
+	if data.BP.ID == "" || data.BP.Version == "" {
+		return nil, fmt.Errorf("buildpack.toml must provide id and version: %s", filepath.Join(bp.Dir, "buildpack.toml"))
+	}
+	if bp.ID != "" && bp.ID != data.BP.ID {
+		return nil, fmt.Errorf("buildpack IDs did not match: %s != %s", bp.ID, data.BP.ID)
+	}
+
+	var problems []string
+	for _, script := range []string{"detect", "build"} {
+		fi, err := os.Stat(filepath.Join(bp.Dir, "bin", script))
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, fmt.Sprintf("bin/%s is missing", script))
+		case err != nil:
+			return nil, err
+		case !fi.Mode().IsRegular() || fi.Mode()&0111 == 0:
+			problems = append(problems, fmt.Sprintf("bin/%s is not executable", script))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("buildpack %s is invalid: %s", style.Symbol(data.BP.ID+"@"+data.BP.Version), strings.Join(problems, ", "))
+	}
+	return data, nil
+}
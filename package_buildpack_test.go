@@ -0,0 +1,199 @@
+package pack_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestPackageBuildpack(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("package-buildpack is not implemented on windows")
+	}
+	spec.Run(t, "package_buildpack", testPackageBuildpack, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testPackageBuildpack(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockDocker     *mocks.MockDocker
+		bpFetcher      *buildpack.Fetcher
+		factory        pack.PackageFactory
+		tmpDir         string
+		outBuf         bytes.Buffer
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = mocks.NewMockDocker(mockController)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "package-buildpack")
+		h.AssertNil(t, err)
+
+		logger := logging.NewLogger(&outBuf, &outBuf, true, false)
+		bpFetcher = buildpack.NewFetcher(logger, nil, tmpDir)
+		factory = pack.PackageFactory{
+			Logger:           logger,
+			Docker:           mockDocker,
+			BuildpackFetcher: bpFetcher,
+		}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#PackageBuildpackConfigFromFlags", func() {
+		it("fetches the buildpacks relative to the package config", func() {
+			config, err := factory.PackageBuildpackConfigFromFlags(pack.PackageBuildpackFlags{
+				Output:     "some-buildpacks.cnb",
+				ConfigPath: filepath.Join("testdata", "package", "package.toml"),
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, config.Output, "some-buildpacks.cnb")
+			h.AssertEq(t, len(config.Buildpacks), 2)
+			h.AssertEq(t, config.Buildpacks[0].Dir, filepath.Join("testdata", "package", "first"))
+			h.AssertEq(t, config.Buildpacks[1].Dir, filepath.Join("testdata", "package", "second"))
+		})
+
+		it("fails when the output is not an archive", func() {
+			_, err := factory.PackageBuildpackConfigFromFlags(pack.PackageBuildpackFlags{
+				Output:     "some/image",
+				ConfigPath: filepath.Join("testdata", "package", "package.toml"),
+			})
+			h.AssertError(t, err, "output 'some/image' must be a .cnb or .tgz file, or an image name with --image")
+		})
+	})
+
+	when("#Package", func() {
+		var packageBuildpacks = func(dirs ...string) []buildpack.Buildpack {
+			var buildpacks []buildpack.Buildpack
+			for _, dir := range dirs {
+				buildpacks = append(buildpacks, buildpack.Buildpack{Dir: filepath.Join("testdata", "package", dir)})
+			}
+			return buildpacks
+		}
+
+		it("creates an archive that can be fetched as a buildpack", func() {
+			output := filepath.Join(tmpDir, "first.cnb")
+			h.AssertNil(t, factory.Package(context.TODO(), pack.PackageBuildpackConfig{
+				Output:     output,
+				Buildpacks: packageBuildpacks("first"),
+			}))
+
+			fetched, err := bpFetcher.FetchBuildpack(".", buildpack.Buildpack{URI: output})
+			h.AssertNil(t, err)
+			contents, err := ioutil.ReadFile(filepath.Join(fetched.Dir, "buildpack.toml"))
+			h.AssertNil(t, err)
+			h.AssertContains(t, string(contents), `id = "example/first"`)
+			fi, err := os.Stat(filepath.Join(fetched.Dir, "bin", "detect"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, fi.Mode().Perm(), os.FileMode(0755))
+		})
+
+		it("creates an archive that is readable by everyone", func() {
+			output := filepath.Join(tmpDir, "first.cnb")
+			h.AssertNil(t, factory.Package(context.TODO(), pack.PackageBuildpackConfig{
+				Output:     output,
+				Buildpacks: packageBuildpacks("first"),
+			}))
+
+			fi, err := os.Stat(output)
+			h.AssertNil(t, err)
+			h.AssertEq(t, fi.Mode().Perm(), os.FileMode(0644))
+		})
+
+		it("creates an archive of several buildpacks from which each can be fetched by ID", func() {
+			output := filepath.Join(tmpDir, "buildpacks.tgz")
+			h.AssertNil(t, factory.Package(context.TODO(), pack.PackageBuildpackConfig{
+				Output:     output,
+				Buildpacks: packageBuildpacks("first", "second"),
+			}))
+
+			for _, id := range []string{"example/first", "example/second"} {
+				fetched, err := bpFetcher.FetchBuildpack(".", buildpack.Buildpack{ID: id, URI: output})
+				h.AssertNil(t, err)
+				h.AssertEq(t, filepath.Base(filepath.Dir(fetched.Dir)), strings.Replace(id, "/", "_", -1))
+				contents, err := ioutil.ReadFile(filepath.Join(fetched.Dir, "buildpack.toml"))
+				h.AssertNil(t, err)
+				h.AssertContains(t, string(contents), `id = "`+id+`"`)
+			}
+		})
+
+		it("creates an image containing the buildpacks", func() {
+			mockDocker.EXPECT().ImageBuild(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, buildContext io.Reader, options dockertypes.ImageBuildOptions) (dockertypes.ImageBuildResponse, error) {
+					h.AssertEq(t, options.Tags, []string{"some/buildpacks"})
+					contents, err := ioutil.ReadAll(buildContext)
+					h.AssertNil(t, err)
+
+					dockerfile, err := h.UntarSingleFile(bytes.NewReader(contents), "Dockerfile")
+					h.AssertNil(t, err)
+					h.AssertEq(t, string(dockerfile), "FROM scratch\nCOPY buildpacks /buildpacks\n")
+					for _, id := range []string{"example_first", "example_second"} {
+						_, err := h.UntarSingleFile(bytes.NewReader(contents), "buildpacks/"+id+"/1.0.0/bin/build")
+						h.AssertNil(t, err)
+					}
+
+					return dockertypes.ImageBuildResponse{
+						Body: ioutil.NopCloser(strings.NewReader(`{"stream":"Successfully built"}`)),
+					}, nil
+				})
+
+			h.AssertNil(t, factory.Package(context.TODO(), pack.PackageBuildpackConfig{
+				Output:     "some/buildpacks",
+				Image:      true,
+				Buildpacks: packageBuildpacks("first", "second"),
+			}))
+		})
+
+		it("fails when a buildpack is in the package more than once", func() {
+			err := factory.Package(context.TODO(), pack.PackageBuildpackConfig{
+				Output:     filepath.Join(tmpDir, "buildpacks.cnb"),
+				Buildpacks: packageBuildpacks("first", "first"),
+			})
+			h.AssertError(t, err, "buildpack 'example/first@1.0.0' is in the package more than once")
+		})
+
+		it("fails when a buildpack has no executable detect and build scripts", func() {
+			err := factory.Package(context.TODO(), pack.PackageBuildpackConfig{
+				Output:     filepath.Join(tmpDir, "buildpack.cnb"),
+				Buildpacks: []buildpack.Buildpack{{Dir: filepath.Join("testdata", "buildpack")}},
+			})
+			h.AssertError(t, err, "buildpack 'some-buildpack-id@some-buildpack-version' is invalid: bin/detect is not executable, bin/build is not executable")
+		})
+
+		it("fails when a buildpack has no bin directory", func() {
+			bpDir := filepath.Join(tmpDir, "no-bin")
+			h.AssertNil(t, os.MkdirAll(bpDir, 0755))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(bpDir, "buildpack.toml"), []byte("[buildpack]\nid = \"some.bp\"\nversion = \"1.2.3\"\n"), 0644))
+
+			err := factory.Package(context.TODO(), pack.PackageBuildpackConfig{
+				Output:     filepath.Join(tmpDir, "buildpack.cnb"),
+				Buildpacks: []buildpack.Buildpack{{Dir: bpDir}},
+			})
+			h.AssertError(t, err, "buildpack 'some.bp@1.2.3' is invalid: bin/detect is missing, bin/build is missing")
+		})
+	})
+}
@@ -3,6 +3,8 @@ package pack
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"sync"
 
 	"github.com/buildpack/lifecycle"
 	"github.com/buildpack/lifecycle/image"
@@ -10,125 +12,400 @@ import (
 
 	"github.com/buildpack/pack/config"
 	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/stack"
 	"github.com/buildpack/pack/style"
 )
 
 type RebaseConfig struct {
 	Image        image.Image
 	NewBaseImage image.Image
+	Force        bool
+	Tag          string
+	BackupTag    string
 }
 
 type RebaseFactory struct {
 	Logger  *logging.Logger
 	Config  *config.Config
 	Fetcher Fetcher
+	Docker  Docker
 }
 
 type RebaseFlags struct {
-	RepoName string
-	Publish  bool
-	NoPull   bool
-	RunImage string
+	RepoName  string
+	Publish   bool
+	NoPull    bool
+	RunImage  string
+	Force     bool
+	Tag       string
+	BackupTag string
 }
 
 func (f *RebaseFactory) RebaseConfigFromFlags(ctx context.Context, flags RebaseFlags) (RebaseConfig, error) {
-	var newImageFn func(string) (image.Image, error)
-	if flags.Publish {
-		newImageFn = f.Fetcher.FetchRemoteImage
-	} else {
-		newImageFn = func(name string) (image.Image, error) {
-			if !flags.NoPull {
-				return f.Fetcher.FetchUpdatedLocalImage(ctx, name, f.Logger.RawVerboseWriter())
+	newImageFn := f.newImageFn(ctx, flags)
 
-			} else {
-				return f.Fetcher.FetchLocalImage(name)
+	appImage, err := newImageFn(flags.RepoName)
+	if err != nil {
+		return RebaseConfig{}, err
+	}
 
-			}
-		}
+	runImageName, err := f.runImageName(appImage, flags)
+	if err != nil {
+		return RebaseConfig{}, err
 	}
 
-	appImage, err := newImageFn(flags.RepoName)
+	baseImage, err := newImageFn(runImageName)
 	if err != nil {
 		return RebaseConfig{}, err
 	}
 
-	var runImageName string
-	if flags.RunImage != "" {
-		runImageName = flags.RunImage
-	} else {
-		contents, err := appImage.Label(lifecycle.MetadataLabel)
-		if err != nil {
-			return RebaseConfig{}, err
-		}
+	return RebaseConfig{
+		Image:        appImage,
+		NewBaseImage: baseImage,
+		Force:        flags.Force,
+		Tag:          flags.Tag,
+		BackupTag:    flags.BackupTag,
+	}, nil
+}
 
-		var appImageMetadata lifecycle.AppImageMetadata
-		if err := json.Unmarshal([]byte(contents), &appImageMetadata); err != nil {
-			return RebaseConfig{}, err
+func (f *RebaseFactory) newImageFn(ctx context.Context, flags RebaseFlags) func(string) (image.Image, error) {
+	if flags.Publish {
+		return f.Fetcher.FetchRemoteImage
+	}
+	return func(name string) (image.Image, error) {
+		if !flags.NoPull {
+			return f.Fetcher.FetchUpdatedLocalImage(ctx, name, f.Logger.RawVerboseWriter())
 		}
+		return f.Fetcher.FetchLocalImage(name)
+	}
+}
 
-		registry, err := config.Registry(flags.RepoName)
-		if err != nil {
-			return RebaseConfig{}, errors.Wrapf(err, "parsing registry from reference '%s'", flags.RepoName)
-		}
+func (f *RebaseFactory) runImageName(appImage image.Image, flags RebaseFlags) (string, error) {
+	if flags.RunImage != "" {
+		return flags.RunImage, nil
+	}
 
-		mirrors := make([]string, 0)
-		if localRunImage := f.Config.GetRunImage(appImageMetadata.Stack.RunImage.Image); localRunImage != nil {
-			mirrors = append(mirrors, localRunImage.Mirrors...)
-		}
-		mirrors = append(mirrors, appImageMetadata.Stack.RunImage.Image)
-		mirrors = append(mirrors, appImageMetadata.Stack.RunImage.Mirrors...)
-		runImageName, err = config.ImageByRegistry(registry, mirrors)
-		if err != nil {
-			return RebaseConfig{}, errors.Wrapf(err, "find image by registry")
-		}
+	contents, err := appImage.Label(lifecycle.MetadataLabel)
+	if err != nil {
+		return "", err
+	}
+
+	var appImageMetadata lifecycle.AppImageMetadata
+	if err := json.Unmarshal([]byte(contents), &appImageMetadata); err != nil {
+		return "", err
+	}
+
+	registry, err := config.Registry(flags.RepoName)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing registry from reference '%s'", flags.RepoName)
+	}
+
+	mirrors := make([]string, 0)
+	if localRunImage := f.Config.GetRunImage(appImageMetadata.Stack.RunImage.Image); localRunImage != nil {
+		mirrors = append(mirrors, localRunImage.Mirrors...)
+	}
+	mirrors = append(mirrors, appImageMetadata.Stack.RunImage.Image)
+	mirrors = append(mirrors, appImageMetadata.Stack.RunImage.Mirrors...)
+	runImageName, err := config.ImageByRegistry(registry, mirrors)
+	if err != nil {
+		return "", errors.Wrapf(err, "find image by registry")
 	}
 
 	if runImageName == "" {
-		return RebaseConfig{}, errors.New("run image must be specified")
+		return "", errors.New("run image must be specified")
 	}
+	return runImageName, nil
+}
 
-	baseImage, err := newImageFn(runImageName)
+type RebaseStatus struct {
+	RepoName        string
+	RunImage        string
+	Stale           bool
+	CurrentSHA      string
+	CurrentTopLayer string
+	LatestSHA       string
+	LatestTopLayer  string
+	Err             error
+}
+
+// CheckAll reports whether each of the given images needs to be rebased without modifying or pulling any image. App
+// images are read from the registry when publishing and from the daemon otherwise. Run images are read from their
+// registry, or from the daemon when NoPull is set. A failure to check one image is recorded in its status and does not
+// stop the others.
+func (f *RebaseFactory) CheckAll(repoNames []string, flags RebaseFlags) []RebaseStatus {
+	appImageFn := f.Fetcher.FetchLocalImage
+	if flags.Publish {
+		appImageFn = f.Fetcher.FetchRemoteImage
+	}
+	runImageFn := f.Fetcher.FetchRemoteImage
+	if flags.NoPull {
+		runImageFn = f.Fetcher.FetchLocalImage
+	}
+	runImages := &runImageCache{fetch: runImageFn, images: map[string]*runImageEntry{}}
+
+	statuses := make([]RebaseStatus, len(repoNames))
+	for i, repoName := range repoNames {
+		statuses[i] = f.checkOne(appImageFn, runImages, repoName, flags)
+	}
+	return statuses
+}
+
+func (f *RebaseFactory) checkOne(appImageFn func(string) (image.Image, error), runImages *runImageCache, repoName string, flags RebaseFlags) RebaseStatus {
+	status := RebaseStatus{RepoName: repoName}
+	flags.RepoName = repoName
+
+	appImage, err := appImageFn(repoName)
 	if err != nil {
-		return RebaseConfig{}, err
+		status.Err = err
+		return status
+	}
+	status.RunImage, err = f.runImageName(appImage, flags)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	baseImage, err := runImages.get(status.RunImage)
+	if err != nil {
+		status.Err = err
+		return status
 	}
 
-	return RebaseConfig{
-		Image:        appImage,
-		NewBaseImage: baseImage,
-	}, nil
+	checked, err := f.Check(RebaseConfig{Image: appImage, NewBaseImage: baseImage})
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	checked.RepoName, checked.RunImage = status.RepoName, status.RunImage
+	return checked
+}
+
+// Check reports whether the app image was built on an older version of the run image than NewBaseImage
+// without modifying either image.
+func (f *RebaseFactory) Check(cfg RebaseConfig) (RebaseStatus, error) {
+	label, err := cfg.Image.Label(lifecycle.MetadataLabel)
+	if err != nil {
+		return RebaseStatus{}, err
+	}
+	var metadata lifecycle.AppImageMetadata
+	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+		return RebaseStatus{}, err
+	}
+
+	status := RebaseStatus{
+		CurrentSHA:      metadata.RunImage.SHA,
+		CurrentTopLayer: metadata.RunImage.TopLayer,
+	}
+	status.LatestSHA, err = cfg.NewBaseImage.Digest()
+	if err != nil {
+		return RebaseStatus{}, err
+	}
+	status.LatestTopLayer, err = cfg.NewBaseImage.TopLayer()
+	if err != nil {
+		return RebaseStatus{}, err
+	}
+
+	// images that only exist on the daemon have no digest, so only compare digests when both are known
+	status.Stale = status.CurrentTopLayer != status.LatestTopLayer ||
+		(status.CurrentSHA != "" && status.LatestSHA != "" && status.CurrentSHA != status.LatestSHA)
+	return status, nil
 }
 
 func (f *RebaseFactory) Rebase(cfg RebaseConfig) error {
+	_, err := f.rebase(cfg)
+	return err
+}
+
+func (f *RebaseFactory) rebase(cfg RebaseConfig) (string, error) {
 	label, err := cfg.Image.Label("io.buildpacks.lifecycle.metadata")
 	if err != nil {
-		return err
+		return "", err
 	}
 	var metadata lifecycle.AppImageMetadata
 	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
-		return err
+		return "", err
+	}
+
+	appStackID, err := cfg.Image.Label(stack.IDLabel)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find stack label for app image %s", style.Symbol(cfg.Image.Name()))
+	}
+	if appStackID == "" {
+		f.Logger.Verbose("App image %s has no %s label, skipping stack validation", style.Symbol(cfg.Image.Name()), style.Symbol(stack.IDLabel))
+	} else if err := validateRunImageStack(f.Logger, cfg.Image.Name(), appStackID, cfg.NewBaseImage, cfg.Force); err != nil {
+		return "", err
+	}
+
+	repoName := cfg.Image.Name()
+	if cfg.BackupTag != "" {
+		cfg.Image.Rename(cfg.BackupTag)
+		if _, err := cfg.Image.Save(); err != nil {
+			return "", errors.Wrapf(err, "saving backup of %s as %s", style.Symbol(repoName), style.Symbol(cfg.BackupTag))
+		}
+		f.Logger.Info("Saved backup of %s as %s", style.Symbol(repoName), style.Symbol(cfg.BackupTag))
 	}
-	f.Logger.Info("Rebasing %s on run image %s", style.Symbol(cfg.Image.Name()), style.Symbol(cfg.NewBaseImage.Name()))
+
+	f.Logger.Info("Rebasing %s on run image %s", style.Symbol(repoName), style.Symbol(cfg.NewBaseImage.Name()))
 	if err := cfg.Image.Rebase(metadata.RunImage.TopLayer, cfg.NewBaseImage); err != nil {
-		return err
+		return "", err
 	}
 
 	metadata.RunImage.SHA, err = cfg.NewBaseImage.Digest()
 	if err != nil {
-		return err
+		return "", err
 	}
 	metadata.RunImage.TopLayer, err = cfg.NewBaseImage.TopLayer()
 	if err != nil {
-		return err
+		return "", err
 	}
 	newLabel, err := json.Marshal(metadata)
 	if err := cfg.Image.SetLabel("io.buildpacks.lifecycle.metadata", string(newLabel)); err != nil {
-		return err
+		return "", err
 	}
 
+	if cfg.Tag != "" {
+		cfg.Image.Rename(cfg.Tag)
+	} else if cfg.BackupTag != "" {
+		cfg.Image.Rename(repoName)
+	}
 	sha, err := cfg.Image.Save()
 	if err != nil {
-		return err
+		return "", err
 	}
 	f.Logger.Info("New sha: %s", style.Symbol(sha))
-	return nil
+	return sha, nil
+}
+
+type RebaseResult struct {
+	RepoName  string
+	OldDigest string
+	NewDigest string
+	Err       error
+}
+
+// RebaseAll rebases each of the given images using at most jobs concurrent workers. Run images are
+// resolved and fetched once per distinct run image name and shared between the app images that use them.
+// A failure to rebase one image is recorded in its result and does not stop the others. The output of each image is
+// written at once when the image is done, so that the output of concurrent rebases is not interleaved.
+func (f *RebaseFactory) RebaseAll(ctx context.Context, repoNames []string, flags RebaseFlags, jobs int) []RebaseResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	var (
+		results   = make([]RebaseResult, len(repoNames))
+		indexes   = make(chan int)
+		wg        sync.WaitGroup
+		flushMu   sync.Mutex
+		runImages = &runImageCache{fetch: f.newImageFn(ctx, flags), images: map[string]*runImageEntry{}}
+	)
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				imageFactory := *f
+				logger, flush := f.Logger.Buffered()
+				imageFactory.Logger = logger
+				results[i] = imageFactory.rebaseOne(imageFactory.newImageFn(ctx, flags), runImages, repoNames[i], flags)
+
+				flushMu.Lock()
+				flush()
+				flushMu.Unlock()
+			}
+		}()
+	}
+
+	for i := range repoNames {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func (f *RebaseFactory) rebaseOne(newImageFn func(string) (image.Image, error), runImages *runImageCache, repoName string, flags RebaseFlags) RebaseResult {
+	result := RebaseResult{RepoName: repoName}
+	flags.RepoName = repoName
+
+	appImage, err := newImageFn(repoName)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	runImageName, err := f.runImageName(appImage, flags)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	baseImage, err := runImages.get(runImageName)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// published images are identified by their registry digest, while images on the daemon may not have one, so they
+	// are identified by their image ID before and after rebasing
+	if flags.Publish {
+		result.OldDigest, err = appImage.Digest()
+	} else {
+		result.OldDigest, err = f.localImageID(repoName)
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	newDigest, err := f.rebase(RebaseConfig{
+		Image:        appImage,
+		NewBaseImage: baseImage,
+		Force:        flags.Force,
+	})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if !flags.Publish && !strings.HasPrefix(newDigest, "sha256:") {
+		newDigest = "sha256:" + newDigest
+	}
+	result.NewDigest = newDigest
+	return result
+}
+
+func (f *RebaseFactory) localImageID(repoName string) (string, error) {
+	inspect, _, err := f.Docker.ImageInspectWithRaw(context.Background(), repoName)
+	if err != nil {
+		return "", errors.Wrapf(err, "inspecting image %s", style.Symbol(repoName))
+	}
+	return inspect.ID, nil
+}
+
+type runImageEntry struct {
+	once  sync.Once
+	image image.Image
+	err   error
+}
+
+type runImageCache struct {
+	fetch  func(string) (image.Image, error)
+	mu     sync.Mutex
+	images map[string]*runImageEntry
+}
+
+func (c *runImageCache) get(name string) (image.Image, error) {
+	c.mu.Lock()
+	entry, ok := c.images[name]
+	if !ok {
+		entry = &runImageEntry{}
+		c.images[name] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.image, entry.err = c.fetch(name)
+	})
+	return entry.image, entry.err
 }
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/fatih/color"
@@ -13,6 +15,7 @@ import (
 	"github.com/buildpack/pack/logging"
 
 	"github.com/buildpack/lifecycle"
+	dockertypes "github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -32,6 +35,7 @@ func testRebase(t *testing.T, when spec.G, it spec.S) {
 		var (
 			mockController *gomock.Controller
 			mockFetcher    *mocks.MockFetcher
+			mockDocker     *mocks.MockDocker
 			factory        pack.RebaseFactory
 			outBuf         bytes.Buffer
 			errBuff        bytes.Buffer
@@ -39,10 +43,12 @@ func testRebase(t *testing.T, when spec.G, it spec.S) {
 		it.Before(func() {
 			mockController = gomock.NewController(t)
 			mockFetcher = mocks.NewMockFetcher(mockController)
+			mockDocker = mocks.NewMockDocker(mockController)
 
 			factory = pack.RebaseFactory{
 				Logger:  logging.NewLogger(&outBuf, &errBuff, false, false),
 				Fetcher: mockFetcher,
+				Docker:  mockDocker,
 				Config: &config.Config{},
 			}
 		})
@@ -252,10 +258,12 @@ func testRebase(t *testing.T, when spec.G, it spec.S) {
 			it("swaps the old base for the new base AND stores new sha for new runimage", func() {
 				mockBaseImage := mocks.NewMockImage(mockController)
 				mockBaseImage.EXPECT().Name().Return("some/base-image").AnyTimes()
+				mockBaseImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil)
 				mockBaseImage.EXPECT().TopLayer().Return("some-top-layer", nil)
 				mockBaseImage.EXPECT().Digest().Return("some-sha", nil)
 				mockImage := mocks.NewMockImage(mockController)
 				mockImage.EXPECT().Name().Return("some/name").AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil)
 				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
 					Return(`{"runimage":{"topLayer":"old-top-layer"}, "app":{"sha":"data"}}`, nil)
 				mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage)
@@ -276,6 +284,294 @@ func testRebase(t *testing.T, when spec.G, it spec.S) {
 				err := factory.Rebase(rebaseConfig)
 				h.AssertNil(t, err)
 			})
+
+			when("tags are provided", func() {
+				var (
+					mockImage     *mocks.MockImage
+					mockBaseImage *mocks.MockImage
+				)
+
+				it.Before(func() {
+					mockBaseImage = mocks.NewMockImage(mockController)
+					mockBaseImage.EXPECT().Name().Return("some/base-image").AnyTimes()
+					mockBaseImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil)
+					mockBaseImage.EXPECT().TopLayer().Return("some-top-layer", nil)
+					mockBaseImage.EXPECT().Digest().Return("some-sha", nil)
+					mockImage = mocks.NewMockImage(mockController)
+					mockImage.EXPECT().Name().Return("some/name").AnyTimes()
+					mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil)
+					mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+						Return(`{"runimage":{"topLayer":"old-top-layer"}, "app":{"sha":"data"}}`, nil)
+				})
+
+				it("saves the rebased image under the new tag", func() {
+					rebase := mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage)
+					setLabel := mockImage.EXPECT().SetLabel("io.buildpacks.lifecycle.metadata", gomock.Any()).After(rebase)
+					rename := mockImage.EXPECT().Rename("some/name:rebased").After(setLabel)
+					mockImage.EXPECT().Save().After(rename).Return("some-digest", nil)
+
+					err := factory.Rebase(pack.RebaseConfig{
+						Image:        mockImage,
+						NewBaseImage: mockBaseImage,
+						Tag:          "some/name:rebased",
+					})
+					h.AssertNil(t, err)
+				})
+
+				it("saves the original image under the backup tag before rebasing", func() {
+					backupRename := mockImage.EXPECT().Rename("some/name:backup")
+					backupSave := mockImage.EXPECT().Save().After(backupRename).Return("backup-digest", nil)
+					rebase := mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage).After(backupSave)
+					setLabel := mockImage.EXPECT().SetLabel("io.buildpacks.lifecycle.metadata", gomock.Any()).After(rebase)
+					rename := mockImage.EXPECT().Rename("some/name").After(setLabel)
+					mockImage.EXPECT().Save().After(rename).Return("some-digest", nil)
+
+					err := factory.Rebase(pack.RebaseConfig{
+						Image:        mockImage,
+						NewBaseImage: mockBaseImage,
+						BackupTag:    "some/name:backup",
+					})
+					h.AssertNil(t, err)
+					h.AssertContains(t, outBuf.String(), "Saved backup of 'some/name' as 'some/name:backup'")
+				})
+			})
+
+			when("the run image stack does not match the app image stack", func() {
+				var (
+					mockImage     *mocks.MockImage
+					mockBaseImage *mocks.MockImage
+				)
+
+				it.Before(func() {
+					mockBaseImage = mocks.NewMockImage(mockController)
+					mockBaseImage.EXPECT().Name().Return("some/base-image").AnyTimes()
+					mockBaseImage.EXPECT().Label("io.buildpacks.stack.id").Return("other.stack.id", nil)
+					mockImage = mocks.NewMockImage(mockController)
+					mockImage.EXPECT().Name().Return("some/name").AnyTimes()
+					mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil)
+					mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+						Return(`{"runimage":{"topLayer":"old-top-layer"}, "app":{"sha":"data"}}`, nil)
+				})
+
+				it("returns an error naming both stacks", func() {
+					err := factory.Rebase(pack.RebaseConfig{
+						Image:        mockImage,
+						NewBaseImage: mockBaseImage,
+					})
+					h.AssertError(t, err, "wrong stack: run image 'some/base-image' has stack 'other.stack.id' but 'some/name' has stack 'some.stack.id' -- use --force to override")
+				})
+
+				it("rebases anyway when forced", func() {
+					mockBaseImage.EXPECT().TopLayer().Return("some-top-layer", nil)
+					mockBaseImage.EXPECT().Digest().Return("some-sha", nil)
+					mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage)
+					mockImage.EXPECT().SetLabel("io.buildpacks.lifecycle.metadata", gomock.Any())
+					mockImage.EXPECT().Save().Return("some-digest", nil)
+
+					err := factory.Rebase(pack.RebaseConfig{
+						Image:        mockImage,
+						NewBaseImage: mockBaseImage,
+						Force:        true,
+					})
+					h.AssertNil(t, err)
+					h.AssertContains(t, outBuf.String(), "Warning: wrong stack: run image 'some/base-image' has stack 'other.stack.id'")
+				})
+			})
+		})
+
+		when("#Check", func() {
+			var (
+				mockImage     *mocks.MockImage
+				mockBaseImage *mocks.MockImage
+			)
+
+			it.Before(func() {
+				mockImage = mocks.NewMockImage(mockController)
+				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+					Return(`{"runimage":{"topLayer":"old-top-layer","sha":"old-sha"}}`, nil)
+				mockBaseImage = mocks.NewMockImage(mockController)
+			})
+
+			it("reports the image as up to date when the run image has not changed", func() {
+				mockBaseImage.EXPECT().Digest().Return("old-sha", nil)
+				mockBaseImage.EXPECT().TopLayer().Return("old-top-layer", nil)
+
+				status, err := factory.Check(pack.RebaseConfig{Image: mockImage, NewBaseImage: mockBaseImage})
+				h.AssertNil(t, err)
+				h.AssertEq(t, status.Stale, false)
+			})
+
+			it("reports the image as stale when the run image top layer has changed", func() {
+				mockBaseImage.EXPECT().Digest().Return("", nil)
+				mockBaseImage.EXPECT().TopLayer().Return("new-top-layer", nil)
+
+				status, err := factory.Check(pack.RebaseConfig{Image: mockImage, NewBaseImage: mockBaseImage})
+				h.AssertNil(t, err)
+				h.AssertEq(t, status.Stale, true)
+				h.AssertEq(t, status.CurrentTopLayer, "old-top-layer")
+				h.AssertEq(t, status.LatestTopLayer, "new-top-layer")
+			})
+
+			it("reports the image as stale when the run image digest has changed", func() {
+				mockBaseImage.EXPECT().Digest().Return("new-sha", nil)
+				mockBaseImage.EXPECT().TopLayer().Return("old-top-layer", nil)
+
+				status, err := factory.Check(pack.RebaseConfig{Image: mockImage, NewBaseImage: mockBaseImage})
+				h.AssertNil(t, err)
+				h.AssertEq(t, status.Stale, true)
+				h.AssertEq(t, status.LatestSHA, "new-sha")
+			})
+		})
+
+		when("#CheckAll", func() {
+			var mockBaseImage *mocks.MockImage
+
+			it.Before(func() {
+				mockBaseImage = mocks.NewMockImage(mockController)
+				mockBaseImage.EXPECT().Digest().Return("new-sha", nil).AnyTimes()
+				mockBaseImage.EXPECT().TopLayer().Return("new-top-layer", nil).AnyTimes()
+			})
+
+			appImage := func(topLayer string) *mocks.MockImage {
+				mockImage := mocks.NewMockImage(mockController)
+				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+					Return(`{"stack":{"runImage":{"image":"some/run"}},"runimage":{"topLayer":"`+topLayer+`","sha":"new-sha"}}`, nil).AnyTimes()
+				return mockImage
+			}
+
+			it("reads the images without pulling them", func() {
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(appImage("new-top-layer"), nil)
+				mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockBaseImage, nil)
+
+				statuses := factory.CheckAll([]string{"some/app"}, pack.RebaseFlags{})
+				h.AssertEq(t, len(statuses), 1)
+				h.AssertNil(t, statuses[0].Err)
+				h.AssertEq(t, statuses[0].RepoName, "some/app")
+				h.AssertEq(t, statuses[0].RunImage, "some/run")
+				h.AssertEq(t, statuses[0].Stale, false)
+			})
+
+			it("reads the run image from the daemon with no-pull", func() {
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(appImage("new-top-layer"), nil)
+				mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockBaseImage, nil)
+
+				statuses := factory.CheckAll([]string{"some/app"}, pack.RebaseFlags{NoPull: true})
+				h.AssertNil(t, statuses[0].Err)
+			})
+
+			it("reads the app image from the registry when publishing", func() {
+				mockFetcher.EXPECT().FetchRemoteImage("some/app").Return(appImage("new-top-layer"), nil)
+				mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockBaseImage, nil)
+
+				statuses := factory.CheckAll([]string{"some/app"}, pack.RebaseFlags{Publish: true})
+				h.AssertNil(t, statuses[0].Err)
+			})
+
+			it("records failures and continues with the remaining images", func() {
+				mockFetcher.EXPECT().FetchLocalImage("some/missing").Return(nil, errors.New("image not found"))
+				mockFetcher.EXPECT().FetchLocalImage("some/stale").Return(appImage("old-top-layer"), nil)
+				mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockBaseImage, nil).Times(1)
+
+				statuses := factory.CheckAll([]string{"some/missing", "some/stale"}, pack.RebaseFlags{})
+				h.AssertEq(t, len(statuses), 2)
+				h.AssertError(t, statuses[0].Err, "image not found")
+				h.AssertNil(t, statuses[1].Err)
+				h.AssertEq(t, statuses[1].Stale, true)
+			})
+		})
+
+		when("#RebaseAll", func() {
+			newAppImage := func(name, id string) *mocks.MockImage {
+				mockImage := mocks.NewMockImage(mockController)
+				mockImage.EXPECT().Name().Return(name).AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+					Return(`{"runimage":{"topLayer":"old-top-layer"}, "stack":{"runImage":{"image":"some/run"}}}`, nil).AnyTimes()
+				mockDocker.EXPECT().ImageInspectWithRaw(gomock.Any(), name).
+					Return(dockertypes.ImageInspect{ID: id}, nil, nil).AnyTimes()
+				return mockImage
+			}
+
+			it("fetches each run image once and rebases every image", func() {
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockBaseImage.EXPECT().Name().Return("some/run").AnyTimes()
+				mockBaseImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+				mockBaseImage.EXPECT().TopLayer().Return("some-top-layer", nil).AnyTimes()
+				mockBaseImage.EXPECT().Digest().Return("some-sha", nil).AnyTimes()
+				mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockBaseImage, nil).Times(1)
+
+				for _, name := range []string{"some/app1", "some/app2", "some/app3"} {
+					mockImage := newAppImage(name, "sha256:"+name+"-old-id")
+					mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage)
+					mockImage.EXPECT().SetLabel("io.buildpacks.lifecycle.metadata", gomock.Any())
+					mockImage.EXPECT().Save().Return(name+"-new-id", nil)
+					mockFetcher.EXPECT().FetchLocalImage(name).Return(mockImage, nil)
+				}
+
+				results := factory.RebaseAll(context.TODO(), []string{"some/app1", "some/app2", "some/app3"}, pack.RebaseFlags{NoPull: true}, 2)
+				h.AssertEq(t, len(results), 3)
+				for i, name := range []string{"some/app1", "some/app2", "some/app3"} {
+					h.AssertEq(t, results[i].RepoName, name)
+					h.AssertNil(t, results[i].Err)
+					h.AssertEq(t, results[i].OldDigest, "sha256:"+name+"-old-id")
+					h.AssertEq(t, results[i].NewDigest, "sha256:"+name+"-new-id")
+					h.AssertContains(t, outBuf.String(), fmt.Sprintf("Rebasing '%s' on run image 'some/run'\nNew sha: '%s-new-id'\n", name, name))
+				}
+			})
+
+			it("records failures and continues with the remaining images", func() {
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockBaseImage.EXPECT().Name().Return("some/run").AnyTimes()
+				mockBaseImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+				mockBaseImage.EXPECT().TopLayer().Return("some-top-layer", nil).AnyTimes()
+				mockBaseImage.EXPECT().Digest().Return("some-sha", nil).AnyTimes()
+				mockFetcher.EXPECT().FetchLocalImage("some/run").Return(mockBaseImage, nil).Times(1)
+
+				mockFetcher.EXPECT().FetchLocalImage("some/missing").Return(nil, errors.New("image not found"))
+
+				failingImage := newAppImage("some/failing", "sha256:failing-old-id")
+				failingImage.EXPECT().Rebase("old-top-layer", mockBaseImage).Return(errors.New("rebase failed"))
+				mockFetcher.EXPECT().FetchLocalImage("some/failing").Return(failingImage, nil)
+
+				mockImage := newAppImage("some/app", "sha256:app-old-id")
+				mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage)
+				mockImage.EXPECT().SetLabel("io.buildpacks.lifecycle.metadata", gomock.Any())
+				mockImage.EXPECT().Save().Return("app-new-id", nil)
+				mockFetcher.EXPECT().FetchLocalImage("some/app").Return(mockImage, nil)
+
+				results := factory.RebaseAll(context.TODO(), []string{"some/missing", "some/failing", "some/app"}, pack.RebaseFlags{NoPull: true}, 1)
+				h.AssertError(t, results[0].Err, "image not found")
+				h.AssertEq(t, results[1].OldDigest, "sha256:failing-old-id")
+				h.AssertError(t, results[1].Err, "rebase failed")
+				h.AssertNil(t, results[2].Err)
+				h.AssertEq(t, results[2].NewDigest, "sha256:app-new-id")
+			})
+
+			it("reports registry digests of published images", func() {
+				mockBaseImage := mocks.NewMockImage(mockController)
+				mockBaseImage.EXPECT().Name().Return("some/run").AnyTimes()
+				mockBaseImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+				mockBaseImage.EXPECT().TopLayer().Return("some-top-layer", nil).AnyTimes()
+				mockBaseImage.EXPECT().Digest().Return("some-sha", nil).AnyTimes()
+				mockFetcher.EXPECT().FetchRemoteImage("some/run").Return(mockBaseImage, nil)
+
+				mockImage := mocks.NewMockImage(mockController)
+				mockImage.EXPECT().Name().Return("some/app").AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+				mockImage.EXPECT().Label("io.buildpacks.lifecycle.metadata").
+					Return(`{"runimage":{"topLayer":"old-top-layer"}, "stack":{"runImage":{"image":"some/run"}}}`, nil).AnyTimes()
+				mockImage.EXPECT().Digest().Return("sha256:old-digest", nil)
+				mockImage.EXPECT().Rebase("old-top-layer", mockBaseImage)
+				mockImage.EXPECT().SetLabel("io.buildpacks.lifecycle.metadata", gomock.Any())
+				mockImage.EXPECT().Save().Return("sha256:new-digest", nil)
+				mockFetcher.EXPECT().FetchRemoteImage("some/app").Return(mockImage, nil)
+
+				factory.Docker = nil
+				results := factory.RebaseAll(context.TODO(), []string{"some/app"}, pack.RebaseFlags{Publish: true}, 1)
+				h.AssertNil(t, results[0].Err)
+				h.AssertEq(t, results[0].OldDigest, "sha256:old-digest")
+				h.AssertEq(t, results[0].NewDigest, "sha256:new-digest")
+			})
 		})
 	})
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/buildpack"
 	"github.com/buildpack/pack/config"
 	"github.com/buildpack/pack/logging"
 	"github.com/buildpack/pack/mocks"
@@ -85,9 +86,11 @@ func testRun(t *testing.T, when spec.G, it spec.S) {
 
 		it("creates args RunConfig derived from args BuildConfig", func() {
 			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
 
 			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
 			mockRunImage.EXPECT().Found().Return(true, nil)
 			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
 
@@ -121,6 +124,36 @@ func testRun(t *testing.T, when spec.G, it spec.S) {
 			}
 		})
 
+		it("fetches git buildpacks with the buildpack fetcher", func() {
+			mockBuildpackFetcher := mocks.NewMockBuildpackFetcher(mockController)
+			factory.BuildpackFetcher = mockBuildpackFetcher
+
+			mockBuilderImage := mocks.NewMockImage(mockController)
+			mockBuilderImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/builder", gomock.Any()).Return(mockBuilderImage, nil)
+
+			mockRunImage := mocks.NewMockImage(mockController)
+			mockRunImage.EXPECT().Label("io.buildpacks.stack.id").Return("some.stack.id", nil).AnyTimes()
+			mockRunImage.EXPECT().Found().Return(true, nil)
+			mockFetcher.EXPECT().FetchUpdatedLocalImage(gomock.Any(), "some/run", gomock.Any()).Return(mockRunImage, nil)
+
+			mockBuildpackFetcher.EXPECT().FetchBuildpack("", buildpack.Buildpack{URI: "git+https://example.com/some-buildpack"}).
+				Return(buildpack.Buildpack{Dir: "/some/fetched/buildpack", Commit: "some-commit"}, nil)
+
+			run, err := factory.RunConfigFromFlags(context.TODO(), &pack.RunFlags{
+				BuildFlags: pack.BuildFlags{
+					AppDir:     "acceptance/testdata/node_app",
+					Builder:    "some/builder",
+					RunImage:   "some/run",
+					Buildpacks: []string{"git+https://example.com/some-buildpack"},
+				},
+			})
+			h.AssertNil(t, err)
+
+			build, ok := run.Build.(*pack.BuildConfig)
+			h.AssertEq(t, ok, true)
+			h.AssertEq(t, build.LifecycleConfig.Buildpacks, []string{"/some/fetched/buildpack"})
+		})
 	})
 
 	when("#Run", func() {
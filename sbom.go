@@ -0,0 +1,35 @@
+package pack
+
+import (
+	"fmt"
+
+	"github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/sbom"
+	"github.com/buildpack/pack/style"
+)
+
+func (c *Client) GenerateBOM(name string, daemon bool) (*sbom.BOM, error) {
+	var (
+		img image.Image
+		err error
+	)
+
+	if daemon {
+		img, err = c.fetcher.FetchLocalImage(name)
+	} else {
+		img, err = c.fetcher.FetchRemoteImage(name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get image '%s'", name)
+	}
+
+	if found, err := img.Found(); err != nil {
+		return nil, errors.Wrapf(err, "failed to find image '%s'", name)
+	} else if !found {
+		return nil, fmt.Errorf("image %s does not exist", style.Symbol(name))
+	}
+
+	return sbom.FromImage(img)
+}
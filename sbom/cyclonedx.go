@@ -0,0 +1,110 @@
+package sbom
+
+import (
+	"strings"
+	"time"
+)
+
+type cdxDocument struct {
+	BOMFormat   string         `json:"bomFormat"`
+	SpecVersion string         `json:"specVersion"`
+	Version     int            `json:"version"`
+	Metadata    cdxMetadata    `json:"metadata"`
+	Components  []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     []cdxTool    `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	Hashes     []cdxHash      `json:"hashes,omitempty"`
+	Properties []cdxProperty  `json:"properties,omitempty"`
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (b *BOM) cycloneDX() cdxDocument {
+	doc := cdxDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.4",
+		Version:     1,
+		Metadata: cdxMetadata{
+			Timestamp: b.Created.UTC().Format(time.RFC3339),
+			Tools:     []cdxTool{{Vendor: "Cloud Native Buildpacks", Name: "pack"}},
+			Component: cdxComponent{
+				Type:    "container",
+				BOMRef:  b.ImageName,
+				Name:    b.ImageName,
+				Version: b.ImageDigest,
+				Hashes:  cdxHashes(b.ImageDigest),
+				Properties: []cdxProperty{
+					{Name: "buildpacks:stack:id", Value: b.Stack},
+				},
+			},
+		},
+	}
+
+	doc.Components = append(doc.Components, cdxComponent{
+		Type:    "container",
+		BOMRef:  "run-image:" + b.RunImage.Image,
+		Name:    b.RunImage.Image,
+		Version: b.RunImage.Digest,
+		Hashes:  cdxHashes(b.RunImage.Digest),
+		Properties: []cdxProperty{
+			{Name: "buildpacks:stack:id", Value: b.Stack},
+			{Name: "buildpacks:run-image:top-layer", Value: b.RunImage.TopLayer},
+		},
+	})
+
+	for _, bp := range b.Buildpacks {
+		component := cdxComponent{
+			Type:    "application",
+			BOMRef:  "buildpack:" + bp.ID + "@" + bp.Version,
+			Name:    bp.ID,
+			Version: bp.Version,
+		}
+		for _, layer := range bp.Layers {
+			layerComponent := cdxComponent{
+				Type:   "file",
+				BOMRef: "layer:" + bp.ID + ":" + layer.Name,
+				Name:   layer.Name,
+				Hashes: cdxHashes(layer.SHA),
+			}
+			if metadata := layerMetadataJSON(layer); metadata != "" {
+				layerComponent.Properties = []cdxProperty{{Name: "buildpacks:layer:metadata", Value: metadata}}
+			}
+			component.Components = append(component.Components, layerComponent)
+		}
+		doc.Components = append(doc.Components, component)
+	}
+
+	return doc
+}
+
+func cdxHashes(digest string) []cdxHash {
+	if hex := strings.TrimPrefix(digest, "sha256:"); hex != "" && hex != digest {
+		return []cdxHash{{Alg: "SHA-256", Content: hex}}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/buildpack/lifecycle"
+	"github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/stack"
+	"github.com/buildpack/pack/style"
+)
+
+const (
+	FormatSPDX      = "spdx"
+	FormatCycloneDX = "cyclonedx"
+)
+
+// Filenames are the names of the files written by WriteDir, by format.
+var Filenames = map[string]string{
+	FormatSPDX:      "sbom.spdx.json",
+	FormatCycloneDX: "sbom.cdx.json",
+}
+
+type BOM struct {
+	ImageName   string
+	ImageDigest string
+	Stack       string
+	RunImage    RunImage
+	Buildpacks  []Buildpack
+	Created     time.Time
+}
+
+type RunImage struct {
+	Image    string
+	TopLayer string
+	Digest   string
+}
+
+type Buildpack struct {
+	ID      string
+	Version string
+	Layers  []Layer
+}
+
+type Layer struct {
+	Name     string
+	SHA      string
+	Metadata interface{}
+}
+
+// FromImage gathers the bill of materials recorded in the labels of an app image built with buildpacks.
+func FromImage(img image.Image) (*BOM, error) {
+	label, err := img.Label(lifecycle.MetadataLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get metadata for image %s", style.Symbol(img.Name()))
+	}
+	if label == "" {
+		return nil, fmt.Errorf("image %s missing label %s -- was it built with buildpacks?", style.Symbol(img.Name()), style.Symbol(lifecycle.MetadataLabel))
+	}
+
+	var metadata lifecycle.AppImageMetadata
+	if err := json.Unmarshal([]byte(label), &metadata); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse metadata for image %s", style.Symbol(img.Name()))
+	}
+
+	stackID, err := img.Label(stack.IDLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get stack for image %s", style.Symbol(img.Name()))
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get digest for image %s", style.Symbol(img.Name()))
+	}
+
+	bom := &BOM{
+		ImageName:   img.Name(),
+		ImageDigest: digest,
+		Stack:       stackID,
+		RunImage: RunImage{
+			Image:    metadata.Stack.RunImage.Image,
+			TopLayer: metadata.RunImage.TopLayer,
+			Digest:   metadata.RunImage.SHA,
+		},
+		Created: time.Now().UTC(),
+	}
+
+	for _, bp := range metadata.Buildpacks {
+		var names []string
+		for name := range bp.Layers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		buildpack := Buildpack{ID: bp.ID, Version: bp.Version}
+		for _, name := range names {
+			buildpack.Layers = append(buildpack.Layers, Layer{
+				Name:     name,
+				SHA:      bp.Layers[name].SHA,
+				Metadata: bp.Layers[name].Data,
+			})
+		}
+		bom.Buildpacks = append(bom.Buildpacks, buildpack)
+	}
+
+	return bom, nil
+}
+
+func (b *BOM) Write(w io.Writer, format string) error {
+	var doc interface{}
+	switch format {
+	case FormatSPDX:
+		doc = b.spdx()
+	case FormatCycloneDX:
+		doc = b.cycloneDX()
+	default:
+		return fmt.Errorf("unknown SBOM format %s, must be one of %s or %s", style.Symbol(format), FormatSPDX, FormatCycloneDX)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// WriteDir writes the bill of materials to dir once in each supported format.
+func (b *BOM) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "creating SBOM directory %s", style.Symbol(dir))
+	}
+	for _, format := range []string{FormatSPDX, FormatCycloneDX} {
+		if err := b.writeFile(filepath.Join(dir, Filenames[format]), format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BOM) writeFile(path, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "creating SBOM file %s", style.Symbol(path))
+	}
+	defer file.Close()
+	return b.Write(file, format)
+}
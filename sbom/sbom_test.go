@@ -0,0 +1,165 @@
+package sbom_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack/sbom"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestSBOM(t *testing.T) {
+	color.NoColor = true
+	spec.Run(t, "sbom", testSBOM, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSBOM(t *testing.T, when spec.G, it spec.S) {
+	var appImage *imgtest.FakeImage
+
+	it.Before(func() {
+		appImage = imgtest.NewFakeImage(t, "some/app", "", "sha256:app-digest")
+	})
+
+	when("#FromImage", func() {
+		when("the image was built with buildpacks", func() {
+			var bom *sbom.BOM
+
+			it.Before(func() {
+				h.AssertNil(t, appImage.SetLabel("io.buildpacks.stack.id", "test.stack.id"))
+				h.AssertNil(t, appImage.SetLabel("io.buildpacks.lifecycle.metadata", `{
+  "buildpacks": [
+    {
+      "key": "test.bp.one",
+      "version": "1.0.0",
+      "layers": {
+        "nodejs": {"sha": "sha256:nodejs", "data": {"version": "10.15.1"}},
+        "node_modules": {"sha": "sha256:modules"}
+      }
+    }
+  ],
+  "runImage": {"topLayer": "sha256:top-layer", "sha": "sha256:run-digest"},
+  "stack": {"runImage": {"image": "some/run-image"}}
+}`))
+				var err error
+				bom, err = sbom.FromImage(appImage)
+				h.AssertNil(t, err)
+			})
+
+			it("reads the image, stack and run image", func() {
+				h.AssertEq(t, bom.ImageName, "some/app")
+				h.AssertEq(t, bom.ImageDigest, "sha256:app-digest")
+				h.AssertEq(t, bom.Stack, "test.stack.id")
+				h.AssertEq(t, bom.RunImage, sbom.RunImage{
+					Image:    "some/run-image",
+					TopLayer: "sha256:top-layer",
+					Digest:   "sha256:run-digest",
+				})
+			})
+
+			it("reads the buildpacks and their layers in order", func() {
+				h.AssertEq(t, len(bom.Buildpacks), 1)
+				h.AssertEq(t, bom.Buildpacks[0].ID, "test.bp.one")
+				h.AssertEq(t, bom.Buildpacks[0].Version, "1.0.0")
+				h.AssertEq(t, len(bom.Buildpacks[0].Layers), 2)
+				h.AssertEq(t, bom.Buildpacks[0].Layers[0].Name, "node_modules")
+				h.AssertEq(t, bom.Buildpacks[0].Layers[1].Name, "nodejs")
+				h.AssertEq(t, bom.Buildpacks[0].Layers[1].SHA, "sha256:nodejs")
+			})
+
+			when("#Write", func() {
+				it("writes an SPDX document", func() {
+					var buf bytes.Buffer
+					h.AssertNil(t, bom.Write(&buf, sbom.FormatSPDX))
+
+					var doc map[string]interface{}
+					h.AssertNil(t, json.Unmarshal(buf.Bytes(), &doc))
+					h.AssertEq(t, doc["spdxVersion"], "SPDX-2.2")
+					h.AssertContains(t, buf.String(), `"SPDXID": "SPDXRef-Buildpack-test.bp.one-1.0.0"`)
+					h.AssertContains(t, buf.String(), `"checksumValue": "run-digest"`)
+					h.AssertContains(t, buf.String(), `"comment": "{\"version\":\"10.15.1\"}"`)
+				})
+
+				it("records that the image descends from the run image", func() {
+					var buf bytes.Buffer
+					h.AssertNil(t, bom.Write(&buf, sbom.FormatSPDX))
+
+					var doc struct {
+						Relationships []struct {
+							SPDXElementID      string `json:"spdxElementId"`
+							RelationshipType   string `json:"relationshipType"`
+							RelatedSPDXElement string `json:"relatedSpdxElement"`
+						} `json:"relationships"`
+					}
+					h.AssertNil(t, json.Unmarshal(buf.Bytes(), &doc))
+					var descendantOf []string
+					for _, r := range doc.Relationships {
+						if r.RelationshipType == "DESCENDANT_OF" {
+							descendantOf = append(descendantOf, r.SPDXElementID+" -> "+r.RelatedSPDXElement)
+						}
+					}
+					h.AssertEq(t, descendantOf, []string{"SPDXRef-Image-some-app -> SPDXRef-RunImage-some-run-image"})
+				})
+
+				it("writes a CycloneDX document", func() {
+					var buf bytes.Buffer
+					h.AssertNil(t, bom.Write(&buf, sbom.FormatCycloneDX))
+
+					var doc map[string]interface{}
+					h.AssertNil(t, json.Unmarshal(buf.Bytes(), &doc))
+					h.AssertEq(t, doc["bomFormat"], "CycloneDX")
+					h.AssertContains(t, buf.String(), `"bom-ref": "buildpack:test.bp.one@1.0.0"`)
+					h.AssertContains(t, buf.String(), `"bom-ref": "layer:test.bp.one:nodejs"`)
+					h.AssertContains(t, buf.String(), `"value": "sha256:top-layer"`)
+				})
+
+				it("returns an error for an unknown format", func() {
+					err := bom.Write(&bytes.Buffer{}, "some-format")
+					h.AssertError(t, err, "unknown SBOM format 'some-format', must be one of spdx or cyclonedx")
+				})
+			})
+
+			when("#WriteDir", func() {
+				var tmpDir string
+
+				it.Before(func() {
+					var err error
+					tmpDir, err = ioutil.TempDir("", "sbom-test")
+					h.AssertNil(t, err)
+				})
+
+				it.After(func() {
+					os.RemoveAll(tmpDir)
+				})
+
+				it("writes a file for each format", func() {
+					dir := filepath.Join(tmpDir, "sbom")
+					h.AssertNil(t, bom.WriteDir(dir))
+
+					spdx, err := ioutil.ReadFile(filepath.Join(dir, "sbom.spdx.json"))
+					h.AssertNil(t, err)
+					h.AssertContains(t, string(spdx), `"spdxVersion": "SPDX-2.2"`)
+
+					cdx, err := ioutil.ReadFile(filepath.Join(dir, "sbom.cdx.json"))
+					h.AssertNil(t, err)
+					h.AssertContains(t, string(cdx), `"bomFormat": "CycloneDX"`)
+				})
+			})
+		})
+
+		when("the image has no metadata label", func() {
+			it("returns an error", func() {
+				_, err := sbom.FromImage(appImage)
+				h.AssertError(t, err, "image 'some/app' missing label 'io.buildpacks.lifecycle.metadata' -- was it built with buildpacks?")
+			})
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const noAssertion = "NOASSERTION"
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string         `json:"SPDXID"`
+	Name             string         `json:"name"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	LicenseDeclared  string         `json:"licenseDeclared"`
+	CopyrightText    string         `json:"copyrightText"`
+	Checksums        []spdxChecksum `json:"checksums,omitempty"`
+	Comment          string         `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+var invalidSPDXIDChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+func spdxID(parts ...string) string {
+	return "SPDXRef-" + invalidSPDXIDChars.ReplaceAllString(strings.Join(parts, "-"), "-")
+}
+
+func (b *BOM) spdx() spdxDocument {
+	imageID := spdxID("Image", b.ImageName)
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              b.ImageName,
+		DocumentNamespace: fmt.Sprintf("https://buildpacks.io/spdxdocs/%x", sha256.Sum256([]byte(b.ImageName+b.Created.String()))),
+		CreationInfo: spdxCreationInfo{
+			Created:  b.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: pack"},
+		},
+		Relationships: []spdxRelationship{
+			{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: imageID},
+		},
+	}
+
+	doc.Packages = append(doc.Packages, newSPDXPackage(imageID, b.ImageName, b.ImageDigest, b.ImageDigest,
+		fmt.Sprintf("stack: %s", b.Stack)))
+
+	runImageID := spdxID("RunImage", b.RunImage.Image)
+	doc.Packages = append(doc.Packages, newSPDXPackage(runImageID, b.RunImage.Image, b.RunImage.Digest, b.RunImage.Digest,
+		fmt.Sprintf("stack: %s, top layer: %s", b.Stack, b.RunImage.TopLayer)))
+	doc.Relationships = append(doc.Relationships, spdxRelationship{
+		SPDXElementID: imageID, RelationshipType: "DESCENDANT_OF", RelatedSPDXElement: runImageID,
+	})
+
+	for _, bp := range b.Buildpacks {
+		bpID := spdxID("Buildpack", bp.ID, bp.Version)
+		doc.Packages = append(doc.Packages, newSPDXPackage(bpID, bp.ID, bp.Version, "", ""))
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID: imageID, RelationshipType: "CONTAINS", RelatedSPDXElement: bpID,
+		})
+
+		for _, layer := range bp.Layers {
+			layerID := spdxID("Layer", bp.ID, layer.Name)
+			doc.Packages = append(doc.Packages, newSPDXPackage(layerID, bp.ID+":"+layer.Name, "", layer.SHA, layerMetadataJSON(layer)))
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID: bpID, RelationshipType: "CONTAINS", RelatedSPDXElement: layerID,
+			})
+		}
+	}
+
+	return doc
+}
+
+func newSPDXPackage(id, name, version, digest, comment string) spdxPackage {
+	pkg := spdxPackage{
+		SPDXID:           id,
+		Name:             name,
+		VersionInfo:      version,
+		DownloadLocation: noAssertion,
+		LicenseConcluded: noAssertion,
+		LicenseDeclared:  noAssertion,
+		CopyrightText:    noAssertion,
+		Comment:          comment,
+	}
+	if hex := strings.TrimPrefix(digest, "sha256:"); hex != "" && hex != digest {
+		pkg.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: hex}}
+	}
+	return pkg
+}
+
+func layerMetadataJSON(layer Layer) string {
+	if layer.Metadata == nil {
+		return ""
+	}
+	contents, err := json.Marshal(layer.Metadata)
+	if err != nil {
+		return ""
+	}
+	return string(contents)
+}
@@ -0,0 +1,32 @@
+package pack
+
+import (
+	"fmt"
+
+	"github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/stack"
+	"github.com/buildpack/pack/style"
+)
+
+// validateRunImageStack ensures runImage belongs to stackID, the stack of the image named by source (a builder
+// or app image). A mismatch is an error unless force is set, in which case it is only reported.
+func validateRunImageStack(logger *logging.Logger, source, stackID string, runImage image.Image, force bool) error {
+	runStackID, err := runImage.Label(stack.IDLabel)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find stack label for run image %s", style.Symbol(runImage.Name()))
+	}
+	if runStackID == stackID {
+		return nil
+	}
+
+	msg := fmt.Sprintf("wrong stack: run image %s has stack %s but %s has stack %s",
+		style.Symbol(runImage.Name()), style.Symbol(runStackID), style.Symbol(source), style.Symbol(stackID))
+	if force {
+		logger.Info("Warning: %s (ignored due to --force)", msg)
+		return nil
+	}
+	return fmt.Errorf("%s -- use --force to override", msg)
+}
@@ -1,5 +1,7 @@
 package stack
 
+const IDLabel = "io.buildpacks.stack.id"
+
 type Metadata struct {
 	RunImage RunImageMetadata `toml:"run-image" json:"runImage"`
 }
@@ -0,0 +1,407 @@
+package pack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/lifecycle"
+	lcimg "github.com/buildpack/lifecycle/image"
+	"github.com/pkg/errors"
+
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/builder"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/style"
+)
+
+type UpdateBuilderFlags struct {
+	BuilderName      string
+	Tag              string
+	AddBuildpacks    []string
+	RemoveBuildpacks []string
+	OrderPath        string
+	Publish          bool
+	NoPull           bool
+	UseCurrentTime   bool
+}
+
+type UpdateBuilderConfig struct {
+	Repo             lcimg.Image
+	StackID          string
+	Metadata         builder.Metadata
+	AddBuildpacks    []buildpack.Buildpack
+	RemoveBuildpacks []string                   // buildpacks to remove, as <id>@<version>
+	Groups           []lifecycle.BuildpackGroup // replaces the detection order of the builder, unless nil
+	Created          time.Time
+}
+
+func (f *BuilderFactory) UpdateBuilderConfigFromFlags(ctx context.Context, flags UpdateBuilderFlags) (UpdateBuilderConfig, error) {
+	var (
+		img lcimg.Image
+		err error
+	)
+	if flags.Publish {
+		img, err = f.Fetcher.FetchRemoteImage(flags.BuilderName)
+	} else if !flags.NoPull {
+		img, err = f.Fetcher.FetchUpdatedLocalImage(ctx, flags.BuilderName, f.Logger.RawVerboseWriter())
+	} else {
+		img, err = f.Fetcher.FetchLocalImage(flags.BuilderName)
+	}
+	if err != nil {
+		return UpdateBuilderConfig{}, errors.Wrapf(err, "opening builder: %s", flags.BuilderName)
+	}
+	if found, err := img.Found(); err != nil {
+		return UpdateBuilderConfig{}, err
+	} else if !found {
+		return UpdateBuilderConfig{}, fmt.Errorf("builder %s does not exist", style.Symbol(flags.BuilderName))
+	}
+
+	bldr := builder.NewBuilder(img, f.Config)
+	stackID, err := bldr.GetStack()
+	if err != nil {
+		return UpdateBuilderConfig{}, err
+	}
+	metadata, err := bldr.GetMetadata()
+	if err != nil {
+		return UpdateBuilderConfig{}, err
+	}
+	created, err := creationTime(flags.UseCurrentTime)
+	if err != nil {
+		return UpdateBuilderConfig{}, err
+	}
+	img = newBuilderImage(img, flags.BuilderName, created, flags.Publish, f.Docker)
+	img.Rename(flags.Tag)
+
+	config := UpdateBuilderConfig{
+		Repo:             img,
+		StackID:          stackID,
+		Metadata:         *metadata,
+		RemoveBuildpacks: flags.RemoveBuildpacks,
+		Created:          created,
+	}
+
+	for _, uri := range flags.AddBuildpacks {
+		fetched, err := f.BuildpackFetcher.FetchBuildpack(".", buildpack.Buildpack{URI: uri})
+		if err != nil {
+			return UpdateBuilderConfig{}, err
+		}
+		config.AddBuildpacks = append(config.AddBuildpacks, fetched)
+	}
+
+	if flags.OrderPath != "" {
+		var o order
+		if _, err := toml.DecodeFile(flags.OrderPath, &o); err != nil {
+			return UpdateBuilderConfig{}, errors.Wrapf(err, "reading order from %s", flags.OrderPath)
+		}
+		config.Groups = o.Groups
+	}
+
+	return config, nil
+}
+
+// Update applies changes to an existing builder as new layers on top of it. Removed buildpacks are hidden by whiteout
+// files, and a new order.toml and metadata label describe the resulting builder.
+func (f *BuilderFactory) Update(config UpdateBuilderConfig) error {
+	tmpDir, err := ioutil.TempDir("", "update-builder")
+	if err != nil {
+		return fmt.Errorf(`failed to create temporary directory: %s`, err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var (
+		kept    []builder.BuildpackMetadata
+		removed []builder.BuildpackMetadata
+	)
+	for _, ref := range config.RemoveBuildpacks {
+		if !strings.Contains(ref, "@") {
+			return fmt.Errorf("invalid buildpack %s: expected <id>@<version>", style.Symbol(ref))
+		}
+	}
+	for _, bp := range config.Metadata.Buildpacks {
+		if containsString(config.RemoveBuildpacks, bp.ID+"@"+bp.Version) {
+			removed = append(removed, bp)
+		} else {
+			kept = append(kept, bp)
+		}
+	}
+	for _, ref := range config.RemoveBuildpacks {
+		if !containsBuildpack(removed, ref) {
+			return fmt.Errorf("buildpack %s is not in builder %s", style.Symbol(ref), style.Symbol(config.Repo.Name()))
+		}
+	}
+
+	added, err := f.readAddedBuildpacks(config.AddBuildpacks, kept)
+	if err != nil {
+		return err
+	}
+	addedData, err := f.readBuildpacks(added)
+	if err != nil {
+		return err
+	}
+	if err := validateBuildpackStacks(config.StackID, addedData); err != nil {
+		return err
+	}
+
+	latest := latestVersions(removed, kept, added, addedData)
+	for i := range kept {
+		if version, ok := latest[kept[i].ID]; ok {
+			kept[i].Latest = kept[i].Version == version
+		}
+	}
+	for i := range added {
+		if version, ok := latest[added[i].ID]; ok {
+			added[i].Latest = addedData[i].BP.Version == version
+		}
+	}
+
+	groups := config.Groups
+	if groups == nil {
+		groups = builderGroups(config.Metadata.Groups)
+	}
+	buildpacks, buildpacksData := builderBuildpacks(kept)
+	buildpacks = append(buildpacks, added...)
+	buildpacksData = append(buildpacksData, addedData...)
+	unused, err := validateGroups(groups, buildpacks, buildpacksData)
+	if err != nil {
+		return err
+	}
+	for _, ref := range unused {
+		f.Logger.Info("Warning: buildpack %s is not used by any group", style.Symbol(ref))
+	}
+
+	if len(removed) > 0 {
+		whiteoutTar, err := f.whiteoutLayer(tmpDir, removed, latest)
+		if err != nil {
+			return fmt.Errorf(`failed to generate layer removing buildpacks: %s`, err)
+		}
+		if err := config.Repo.AddLayer(whiteoutTar); err != nil {
+			return fmt.Errorf(`failed append layer removing buildpacks to image: %s`, err)
+		}
+	}
+
+	buildpacksMetadata := kept
+	for i, bp := range added {
+		f.Logger.Verbose("Adding buildpack %s", style.Symbol(bp.ID+"@"+addedData[i].BP.Version))
+		tarFile, err := f.buildpackLayer(tmpDir, bp, addedData[i])
+		if err != nil {
+			return fmt.Errorf(`failed to generate layer for buildpack %s: %s`, style.Symbol(bp.ID), err)
+		}
+		if err := config.Repo.AddLayer(tarFile); err != nil {
+			return fmt.Errorf(`failed append buildpack layer to image: %s`, err)
+		}
+		md := addedData[i].metadata(bp.Latest)
+		md.Commit = bp.Commit
+		md.SourceDigest = bp.SourceDigest
+		if md.LayerDiffID, err = layerDiffID(tarFile); err != nil {
+			return fmt.Errorf(`failed to compute diff ID of buildpack layer: %s`, err)
+		}
+		buildpacksMetadata = append(buildpacksMetadata, md)
+	}
+
+	if len(latest) > 0 {
+		latestTar, err := f.latestLinkLayer(tmpDir, latest)
+		if err != nil {
+			return fmt.Errorf(`failed generate layer for latest links: %s`, err)
+		}
+		if err := config.Repo.AddLayer(latestTar); err != nil {
+			return fmt.Errorf(`failed append latest link layer to image: %s`, err)
+		}
+	}
+
+	orderTar, err := f.orderLayer(tmpDir, groups)
+	if err != nil {
+		return fmt.Errorf(`failed to generate order.toml layer: %s`, err)
+	}
+	if err := config.Repo.AddLayer(orderTar); err != nil {
+		return fmt.Errorf(`failed append order.toml layer to image: %s`, err)
+	}
+
+	metadata := config.Metadata
+	metadata.Buildpacks = buildpacksMetadata
+	metadata.Groups = groupsMetadata(groups)
+	if metadata.CreatedBy != nil {
+		createdBy := *metadata.CreatedBy
+		createdBy.PackVersion = f.PackVersion
+		createdBy.Created = config.Created
+		metadata.CreatedBy = &createdBy
+	}
+	jsonBytes, err := json.Marshal(&metadata)
+	if err != nil {
+		return fmt.Errorf(`failed marshal builder image metadata: %s`, err)
+	}
+	if err := config.Repo.SetLabel(builder.MetadataLabel, string(jsonBytes)); err != nil {
+		return fmt.Errorf("failed to set metadata label: %s", err)
+	}
+
+	if _, err := config.Repo.Save(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readAddedBuildpacks fills in the IDs of buildpacks to add from their buildpack.toml.
+func (f *BuilderFactory) readAddedBuildpacks(add []buildpack.Buildpack, existing []builder.BuildpackMetadata) ([]buildpack.Buildpack, error) {
+	var added []buildpack.Buildpack
+	for _, bp := range add {
+		data, err := f.buildpackData(bp, bp.Dir)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to read buildpack from %s: %s`, style.Symbol(bp.URI), err)
+		}
+		bp.ID = data.BP.ID
+		for _, other := range existing {
+			if other.ID == bp.ID && other.Version == data.BP.Version {
+				return nil, fmt.Errorf("buildpack %s is already in the builder", style.Symbol(bp.ID+"@"+other.Version))
+			}
+		}
+		added = append(added, bp)
+	}
+	return added, nil
+}
+
+// latestVersions returns the highest version left in the builder of each buildpack that versions are removed from or
+// added to, which the 'latest' link of the buildpack is pointed to. A buildpack without any version left is missing.
+func latestVersions(removed, kept []builder.BuildpackMetadata, added []buildpack.Buildpack, addedData []*BuildpackData) map[string]string {
+	changed := map[string]bool{}
+	for _, bp := range removed {
+		changed[bp.ID] = true
+	}
+	for _, bp := range added {
+		changed[bp.ID] = true
+	}
+
+	latest := map[string]string{}
+	consider := func(id, version string) {
+		if current, ok := latest[id]; changed[id] && (!ok || compareVersions(version, current) > 0) {
+			latest[id] = version
+		}
+	}
+	for _, bp := range kept {
+		consider(bp.ID, bp.Version)
+	}
+	for i, bp := range added {
+		consider(bp.ID, addedData[i].BP.Version)
+	}
+	return latest
+}
+
+// compareVersions compares two buildpack versions part by part, comparing the numeric parts of dotted versions as
+// numbers and the other parts as strings. It returns a negative number, zero or a positive number when a is lower
+// than, equal to or higher than b.
+func compareVersions(a, b string) int {
+	isSeparator := func(r rune) bool { return r == '.' || r == '-' || r == '+' }
+	aParts, bParts := strings.FieldsFunc(a, isSeparator), strings.FieldsFunc(b, isSeparator)
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		if aErr == nil && bErr == nil {
+			if aNum != bNum {
+				return aNum - bNum
+			}
+		} else if aParts[i] != bParts[i] {
+			return strings.Compare(aParts[i], bParts[i])
+		}
+	}
+	return len(aParts) - len(bParts)
+}
+
+// latestLinkLayer creates a layer pointing the 'latest' link of each buildpack to the given version.
+func (f *BuilderFactory) latestLinkLayer(dest string, latest map[string]string) (string, error) {
+	layerDir := filepath.Join(dest, "latest-layer")
+	for id, version := range latest {
+		escapedID := strings.Replace(id, "/", "_", -1)
+		if err := os.MkdirAll(filepath.Join(layerDir, escapedID), 0755); err != nil {
+			return "", err
+		}
+		if err := os.Symlink(filepath.Join("/", "buildpacks", escapedID, version), filepath.Join(layerDir, escapedID, "latest")); err != nil {
+			return "", err
+		}
+	}
+
+	tarFile := filepath.Join(dest, "latest.buildpacks.tar")
+	if err := archive.CreateNormalizedTar(tarFile, layerDir, "/buildpacks", 0, 0); err != nil {
+		return "", err
+	}
+	return tarFile, nil
+}
+
+// whiteoutLayer creates a layer hiding the directories of the removed buildpacks, and the 'latest' links of those
+// without any version left.
+func (f *BuilderFactory) whiteoutLayer(dest string, removed []builder.BuildpackMetadata, latest map[string]string) (string, error) {
+	layerDir := filepath.Join(dest, "whiteout-layer")
+	for _, bp := range removed {
+		idDir := filepath.Join(layerDir, strings.Replace(bp.ID, "/", "_", -1))
+		if err := os.MkdirAll(idDir, 0755); err != nil {
+			return "", err
+		}
+		whiteouts := []string{".wh." + bp.Version}
+		if _, ok := latest[bp.ID]; bp.Latest && !ok {
+			whiteouts = append(whiteouts, ".wh.latest")
+		}
+		for _, name := range whiteouts {
+			if err := ioutil.WriteFile(filepath.Join(idDir, name), nil, 0644); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	tarFile := filepath.Join(dest, "whiteout.tar")
+	if err := archive.CreateNormalizedTar(tarFile, layerDir, "/buildpacks", 0, 0); err != nil {
+		return "", err
+	}
+	return tarFile, nil
+}
+
+func builderGroups(groups []builder.GroupMetadata) []lifecycle.BuildpackGroup {
+	var result []lifecycle.BuildpackGroup
+	for _, group := range groups {
+		var buildpacks []*lifecycle.Buildpack
+		for _, bp := range group.Buildpacks {
+			buildpacks = append(buildpacks, &lifecycle.Buildpack{ID: bp.ID, Version: bp.Version, Optional: bp.Optional})
+		}
+		result = append(result, lifecycle.BuildpackGroup{Buildpacks: buildpacks})
+	}
+	return result
+}
+
+// builderBuildpacks describes the buildpacks already in a builder in the form its groups are validated against.
+func builderBuildpacks(metadata []builder.BuildpackMetadata) ([]buildpack.Buildpack, []*BuildpackData) {
+	var (
+		buildpacks []buildpack.Buildpack
+		data       []*BuildpackData
+	)
+	for _, bp := range metadata {
+		buildpacks = append(buildpacks, buildpack.Buildpack{ID: bp.ID, Latest: bp.Latest})
+		d := &BuildpackData{}
+		d.BP.ID = bp.ID
+		d.BP.Version = bp.Version
+		data = append(data, d)
+	}
+	return buildpacks, data
+}
+
+func containsBuildpack(buildpacks []builder.BuildpackMetadata, ref string) bool {
+	for _, bp := range buildpacks {
+		if bp.ID+"@"+bp.Version == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
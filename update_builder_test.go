@@ -0,0 +1,381 @@
+package pack_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/buildpack/lifecycle"
+	imgtest "github.com/buildpack/lifecycle/testhelpers"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpack/pack"
+	"github.com/buildpack/pack/archive"
+	"github.com/buildpack/pack/builder"
+	"github.com/buildpack/pack/buildpack"
+	"github.com/buildpack/pack/config"
+	"github.com/buildpack/pack/logging"
+	"github.com/buildpack/pack/mocks"
+	h "github.com/buildpack/pack/testhelpers"
+)
+
+func TestUpdateBuilder(t *testing.T) {
+	color.NoColor = true
+	if runtime.GOOS == "windows" {
+		t.Skip("update builder is not implemented on windows")
+	}
+	spec.Run(t, "update_builder", testUpdateBuilder, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testUpdateBuilder(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockFetcher    *mocks.MockFetcher
+		mockDocker     *mocks.MockDocker
+		factory        pack.BuilderFactory
+		packHome       string
+		outBuf         bytes.Buffer
+		metadata       builder.Metadata
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockFetcher = mocks.NewMockFetcher(mockController)
+		mockDocker = mocks.NewMockDocker(mockController)
+
+		var err error
+		packHome, err = ioutil.TempDir("", ".pack")
+		h.AssertNil(t, err)
+		cfg, err := config.New(packHome)
+		h.AssertNil(t, err)
+
+		logger := logging.NewLogger(&outBuf, &outBuf, true, false)
+		factory = pack.BuilderFactory{
+			PackVersion:      "1.2.3",
+			Logger:           logger,
+			Config:           cfg,
+			Fetcher:          mockFetcher,
+			BuildpackFetcher: buildpack.NewFetcher(logger, mockFetcher, cfg.Path()),
+			Docker:           mockDocker,
+		}
+
+		metadata = builder.Metadata{
+			Buildpacks: []builder.BuildpackMetadata{
+				{ID: "some-buildpack-id", Version: "some-buildpack-version", Latest: true},
+				{ID: "other.bp", Version: "1.0.0", Latest: true},
+			},
+			Groups: []builder.GroupMetadata{
+				{Buildpacks: []builder.BuildpackMetadata{
+					{ID: "some-buildpack-id", Version: "some-buildpack-version"},
+					{ID: "other.bp", Version: "1.0.0", Optional: true},
+				}},
+			},
+		}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+		os.RemoveAll(packHome)
+	})
+
+	when("#UpdateBuilderConfigFromFlags", func() {
+		it("reads the builder and renames it to the new tag", func() {
+			builderImage := imgtest.NewFakeImage(t, "some/builder", "", "")
+			label, err := json.Marshal(metadata)
+			h.AssertNil(t, err)
+			h.AssertNil(t, builderImage.SetLabel("io.buildpacks.builder.metadata", string(label)))
+			h.AssertNil(t, builderImage.SetLabel("io.buildpacks.stack.id", "some.stack.id"))
+			mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(builderImage, nil)
+
+			orderFile, err := ioutil.TempFile("", "order.toml")
+			h.AssertNil(t, err)
+			defer os.Remove(orderFile.Name())
+			_, err = orderFile.WriteString(`
+[[groups]]
+  [[groups.buildpacks]]
+    id = "some-buildpack-id"
+    version = "some-buildpack-version"
+`)
+			h.AssertNil(t, err)
+			orderFile.Close()
+
+			cfg, err := factory.UpdateBuilderConfigFromFlags(context.TODO(), pack.UpdateBuilderFlags{
+				BuilderName:      "some/builder",
+				Tag:              "some/builder:extended",
+				AddBuildpacks:    []string{filepath.Join("testdata", "buildpack-any-stack")},
+				RemoveBuildpacks: []string{"other.bp@1.0.0"},
+				OrderPath:        orderFile.Name(),
+				NoPull:           true,
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, cfg.Repo.Name(), "some/builder:extended")
+			h.AssertEq(t, cfg.StackID, "some.stack.id")
+			h.AssertEq(t, cfg.Created, archive.NormalizedDateTime)
+			h.AssertEq(t, cfg.Metadata, metadata)
+			h.AssertEq(t, cfg.AddBuildpacks[0].Dir, filepath.Join("testdata", "buildpack-any-stack"))
+			h.AssertEq(t, cfg.RemoveBuildpacks, []string{"other.bp@1.0.0"})
+			h.AssertEq(t, cfg.Groups, []lifecycle.BuildpackGroup{
+				{Buildpacks: []*lifecycle.Buildpack{{ID: "some-buildpack-id", Version: "some-buildpack-version"}}},
+			})
+		})
+
+		it("fails when the builder does not exist", func() {
+			builderImage := imgtest.NewFakeImage(t, "some/builder", "", "")
+			h.AssertNil(t, builderImage.Delete())
+			mockFetcher.EXPECT().FetchLocalImage("some/builder").Return(builderImage, nil)
+
+			_, err := factory.UpdateBuilderConfigFromFlags(context.TODO(), pack.UpdateBuilderFlags{
+				BuilderName: "some/builder",
+				Tag:         "some/builder:extended",
+				NoPull:      true,
+			})
+			h.AssertError(t, err, "builder 'some/builder' does not exist")
+		})
+	})
+
+	when("#Update", func() {
+		var (
+			mockImage     *mocks.MockImage
+			savedLayers   map[string]*bytes.Buffer
+			labels        map[string]string
+			updateConfig  pack.UpdateBuilderConfig
+			savedMetadata func() builder.Metadata
+		)
+
+		it.Before(func() {
+			savedLayers = make(map[string]*bytes.Buffer)
+			labels = make(map[string]string)
+
+			mockImage = mocks.NewMockImage(mockController)
+			mockImage.EXPECT().Name().Return("some/builder:extended").AnyTimes()
+
+			updateConfig = pack.UpdateBuilderConfig{
+				Repo:     mockImage,
+				StackID:  "some.stack.id",
+				Metadata: metadata,
+			}
+
+			savedMetadata = func() builder.Metadata {
+				var md builder.Metadata
+				h.AssertNil(t, json.Unmarshal([]byte(labels["io.buildpacks.builder.metadata"]), &md))
+				return md
+			}
+		})
+
+		expectSave := func() {
+			mockImage.EXPECT().AddLayer(gomock.Any()).Do(func(layerPath string) {
+				buf, err := ioutil.ReadFile(layerPath)
+				h.AssertNil(t, err)
+				savedLayers[filepath.Base(layerPath)] = bytes.NewBuffer(buf)
+			}).AnyTimes()
+			mockImage.EXPECT().SetLabel(gomock.Any(), gomock.Any()).Do(func(labelName, labelValue string) {
+				labels[labelName] = labelValue
+			})
+			mockImage.EXPECT().Save()
+		}
+
+		it("adds a buildpack as a new layer", func() {
+			expectSave()
+			updateConfig.AddBuildpacks = []buildpack.Buildpack{{Dir: filepath.Join("testdata", "buildpack-any-stack")}}
+
+			h.AssertNil(t, factory.Update(updateConfig))
+
+			_, exists := savedLayers["any-stack-buildpack-id.any-stack-buildpack-version.tar"]
+			h.AssertEq(t, exists, true)
+			tr := tar.NewReader(savedLayers["latest.buildpacks.tar"])
+			var linkTarget string
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				h.AssertNil(t, err)
+				if hdr.Name == "/buildpacks/any-stack-buildpack-id/latest" {
+					linkTarget = hdr.Linkname
+				}
+			}
+			h.AssertEq(t, linkTarget, "/buildpacks/any-stack-buildpack-id/any-stack-buildpack-version")
+
+			md := savedMetadata()
+			h.AssertEq(t, len(md.Buildpacks), 3)
+			h.AssertEq(t, md.Buildpacks[2].ID, "any-stack-buildpack-id")
+			h.AssertEq(t, md.Buildpacks[2].Latest, true)
+			h.AssertEq(t, md.Groups, metadata.Groups)
+			h.AssertContains(t, outBuf.String(), "Warning: buildpack 'any-stack-buildpack-id@any-stack-buildpack-version' is not used by any group")
+		})
+
+		it("records the commit of a buildpack fetched from git", func() {
+			expectSave()
+			updateConfig.AddBuildpacks = []buildpack.Buildpack{{
+				Dir:    filepath.Join("testdata", "buildpack-any-stack"),
+				Commit: "0123456789abcdef0123456789abcdef01234567",
+			}}
+
+			h.AssertNil(t, factory.Update(updateConfig))
+
+			md := savedMetadata()
+			h.AssertEq(t, md.Buildpacks[2].Commit, "0123456789abcdef0123456789abcdef01234567")
+		})
+
+		it("removes a buildpack and rewrites the detection order", func() {
+			expectSave()
+			updateConfig.RemoveBuildpacks = []string{"other.bp@1.0.0"}
+			updateConfig.Groups = []lifecycle.BuildpackGroup{
+				{Buildpacks: []*lifecycle.Buildpack{{ID: "some-buildpack-id", Version: "some-buildpack-version"}}},
+			}
+
+			h.AssertNil(t, factory.Update(updateConfig))
+
+			_, err := h.UntarSingleFile(bytes.NewReader(savedLayers["whiteout.tar"].Bytes()), "/buildpacks/other.bp/.wh.1.0.0")
+			h.AssertNil(t, err)
+			_, err = h.UntarSingleFile(bytes.NewReader(savedLayers["whiteout.tar"].Bytes()), "/buildpacks/other.bp/.wh.latest")
+			h.AssertNil(t, err)
+
+			contents, err := h.UntarSingleFile(savedLayers["order.tar"], "/buildpacks/order.toml")
+			h.AssertNil(t, err)
+			h.AssertNotContains(t, string(contents), "other.bp")
+
+			md := savedMetadata()
+			h.AssertEq(t, md.Buildpacks, metadata.Buildpacks[:1])
+			h.AssertEq(t, len(md.Groups[0].Buildpacks), 1)
+		})
+
+		when("the builder has several versions of a buildpack", func() {
+			var tmpDir string
+
+			it.Before(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "update-builder-test")
+				h.AssertNil(t, err)
+
+				updateConfig.Metadata.Buildpacks = append(
+					[]builder.BuildpackMetadata{{ID: "other.bp", Version: "0.9.0"}},
+					metadata.Buildpacks...,
+				)
+			})
+
+			it.After(func() {
+				os.RemoveAll(tmpDir)
+			})
+
+			otherBuildpack := func(version string) buildpack.Buildpack {
+				dir := filepath.Join(tmpDir, version)
+				h.AssertNil(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(dir, "buildpack.toml"), []byte(fmt.Sprintf(`
+[buildpack]
+id = "other.bp"
+version = %q
+
+[[stacks]]
+id = "some.stack.id"
+`, version)), 0644))
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(dir, "bin", "detect"), []byte("detect"), 0755))
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(dir, "bin", "build"), []byte("build"), 0755))
+				return buildpack.Buildpack{Dir: dir}
+			}
+
+			latestOf := func(id string) (link string, versions []string) {
+				tr := tar.NewReader(savedLayers["latest.buildpacks.tar"])
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					h.AssertNil(t, err)
+					if hdr.Name == "/buildpacks/"+id+"/latest" {
+						link = hdr.Linkname
+					}
+				}
+				for _, bp := range savedMetadata().Buildpacks {
+					if bp.ID == id && bp.Latest {
+						versions = append(versions, bp.Version)
+					}
+				}
+				return link, versions
+			}
+
+			it("points 'latest' to the highest version left when the latest version is removed", func() {
+				expectSave()
+				updateConfig.RemoveBuildpacks = []string{"other.bp@1.0.0"}
+				updateConfig.Groups = []lifecycle.BuildpackGroup{
+					{Buildpacks: []*lifecycle.Buildpack{{ID: "other.bp", Version: "latest"}}},
+				}
+
+				h.AssertNil(t, factory.Update(updateConfig))
+
+				_, err := h.UntarSingleFile(bytes.NewReader(savedLayers["whiteout.tar"].Bytes()), "/buildpacks/other.bp/.wh.1.0.0")
+				h.AssertNil(t, err)
+				_, err = h.UntarSingleFile(bytes.NewReader(savedLayers["whiteout.tar"].Bytes()), "/buildpacks/other.bp/.wh.latest")
+				h.AssertNotNil(t, err)
+
+				link, versions := latestOf("other.bp")
+				h.AssertEq(t, link, "/buildpacks/other.bp/0.9.0")
+				h.AssertEq(t, versions, []string{"0.9.0"})
+			})
+
+			it("points 'latest' to an added version that is higher than the others", func() {
+				expectSave()
+				updateConfig.AddBuildpacks = []buildpack.Buildpack{otherBuildpack("1.10.0")}
+
+				h.AssertNil(t, factory.Update(updateConfig))
+
+				link, versions := latestOf("other.bp")
+				h.AssertEq(t, link, "/buildpacks/other.bp/1.10.0")
+				h.AssertEq(t, versions, []string{"1.10.0"})
+			})
+
+			it("keeps 'latest' when an added version is lower than the others", func() {
+				expectSave()
+				updateConfig.AddBuildpacks = []buildpack.Buildpack{otherBuildpack("0.9.5")}
+
+				h.AssertNil(t, factory.Update(updateConfig))
+
+				link, versions := latestOf("other.bp")
+				h.AssertEq(t, link, "/buildpacks/other.bp/1.0.0")
+				h.AssertEq(t, versions, []string{"1.0.0"})
+			})
+		})
+
+		it("fails when a removed buildpack is still in the detection order", func() {
+			updateConfig.RemoveBuildpacks = []string{"other.bp@1.0.0"}
+
+			err := factory.Update(updateConfig)
+			h.AssertError(t, err, `groups reference buildpacks that are not in the builder:
+  group #1: 'other.bp@1.0.0'`)
+		})
+
+		it("fails when a removed buildpack is not in the builder", func() {
+			updateConfig.RemoveBuildpacks = []string{"other.bp@2.0.0"}
+
+			err := factory.Update(updateConfig)
+			h.AssertError(t, err, "buildpack 'other.bp@2.0.0' is not in builder 'some/builder:extended'")
+		})
+
+		it("fails when an added buildpack is already in the builder", func() {
+			updateConfig.AddBuildpacks = []buildpack.Buildpack{{Dir: filepath.Join("testdata", "buildpack")}}
+
+			err := factory.Update(updateConfig)
+			h.AssertError(t, err, "buildpack 'some-buildpack-id@some-buildpack-version' is already in the builder")
+		})
+
+		it("fails when an added buildpack does not support the stack of the builder", func() {
+			updateConfig.StackID = "some.unsupported.stack.id"
+			updateConfig.RemoveBuildpacks = []string{"some-buildpack-id@some-buildpack-version"}
+			updateConfig.AddBuildpacks = []buildpack.Buildpack{{Dir: filepath.Join("testdata", "buildpack")}}
+
+			err := factory.Update(updateConfig)
+			h.AssertError(t, err, "buildpacks are not compatible with stack 'some.unsupported.stack.id'")
+		})
+	})
+}